@@ -33,10 +33,30 @@ function resolveBinary() {
   return join(__dirname, binaryName);
 }
 
+/**
+ * Detects the package manager that launched this script (for example
+ * `yarn create ekko-app` or `bunx create-ekko-app`) from the user agent
+ * every package manager sets for the processes it runs.
+ *
+ * @returns {string | undefined}
+ */
+function detectPackageManager() {
+  const userAgent = process.env.npm_config_user_agent ?? '';
+  const name = userAgent.split(' ')[0].split('/')[0];
+  return ['pnpm', 'npm', 'yarn', 'bun'].includes(name) ? name : undefined;
+}
+
 const binPath = resolveBinary();
 
+const env = { ...process.env };
+const packageManager = detectPackageManager();
+if (packageManager && !env.CREATE_EKKO_APP_PACKAGE_MANAGER) {
+  env.CREATE_EKKO_APP_PACKAGE_MANAGER = packageManager;
+}
+
 const result = spawnSync(binPath, process.argv.slice(2), {
   stdio: 'inherit',
+  env,
 });
 
 if (result.error) {
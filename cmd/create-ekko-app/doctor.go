@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mikekenway/create-ekko-app/internal/doctor"
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// runDoctor implements `create-ekko-app doctor` and returns the exit code.
+func runDoctor(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	flagPackageManager := fs.String("package-manager", "", "package manager to check (pnpm, npm, yarn, bun); defaults to the one that launched the CLI")
+	fs.Parse(args)
+
+	pm := options.DetectPackageManager()
+	if *flagPackageManager != "" {
+		var err error
+		if pm, err = options.ParsePackageManager(*flagPackageManager); err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --package-manager:", err)
+			return 2
+		}
+	}
+
+	results := doctor.New().Check(ctx, pm)
+	if err := doctor.WriteTable(os.Stdout, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if doctor.HasFailures(results) {
+		fmt.Fprintln(os.Stderr, "\nSome required tools are missing or too old.")
+		return 1
+	}
+	return 0
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/charmbracelet/log"
+	"github.com/mattn/go-isatty"
 
 	"github.com/mikekenway/create-ekko-app/internal/options"
 	"github.com/mikekenway/create-ekko-app/internal/scaffold"
@@ -18,9 +21,54 @@ import (
 var version = "dev"
 
 func main() {
+	// Subcommands only match the first argument, so a project named like one
+	// is still reachable after the usual flag terminator:
+	// create-ekko-app -- resume.
+	if len(os.Args) > 1 {
+		subcommands := map[string]func(context.Context, []string) int{
+			"doctor": runDoctor,
+			"resume": runResume,
+		}
+		if run, ok := subcommands[os.Args[1]]; ok {
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			code := run(ctx, os.Args[2:])
+			cancel()
+			os.Exit(code)
+		}
+	}
+
 	flagVersion := flag.Bool("version", false, "print version and exit")
+	flagFramework := flag.String("framework", "", "framework to scaffold (next, tanstack-start)")
+	flagAuth := flag.String("auth", "", "auth package (none, clerk, better-auth)")
+	flagDatabase := flag.String("database", "", "database (none, convex, drizzle)")
+	flagTooling := flag.String("tooling", "", "comma-separated tooling (tanstack-query, tanstack-form, shadcn, react-email, resend)")
+	flagShadcnColor := flag.String("shadcn-color", "", "shadcn base color (neutral, gray, zinc, stone, slate)")
+	flagPackageManager := flag.String("package-manager", "", "package manager (pnpm, npm, yarn, bun); defaults to the one that launched the CLI")
+	flagSkipShadcn := flag.Bool("skip-shadcn", false, "skip shadcn init and component installation")
+	flagDir := flag.String("dir", "", "directory to scaffold into, relative to the current directory (defaults to the project name)")
+	flagVersions := flag.String("versions", "", "pin packages to the versions in a JSON or YAML `file` mapping npm package names to versions, on top of the built-in manifest")
+	flagLatest := flag.Bool("latest", false, "install the latest release of every scaffolder and package instead of the pinned versions")
+	flagForce := flag.Bool("force", false, "scaffold into the project directory even if it already exists, as long as it only holds files the scaffolders keep (.git, .gitignore, LICENSE, ...)")
+	flagCleanup := flag.Bool("cleanup-on-failure", false, "remove everything this run created if scaffolding fails or is cancelled")
+	flagPlain := flag.Bool("plain", false, "print install progress as plain lines instead of the interactive view (default when stdout is not a terminal)")
+	flagLogFile := flag.String("log-file", "", "append the full install log to `path` instead of .ekko/install.log in the project")
+	flagOutput := flag.String("output", "text", "install output format (text, jsonl); jsonl writes one JSON event per line to stdout and requires --yes")
+	flagYes := flag.Bool("yes", false, "skip all prompts and scaffold using flags and defaults")
+	flagConfig := flag.String("config", "", "load selections from a JSON or YAML config file")
+	flagPreset := flag.String("preset", "", "start from a named preset (saas, marketing, or a user preset)")
+	var flagDryRun dryRunFlag
+	flag.Var(&flagDryRun, "dry-run", "print the scaffold plan instead of running it (--dry-run or --dry-run=json)")
+	flagEmitScript := flag.String("emit-script", "", "write the scaffold plan as a shell script to `path` (- for stdout) instead of running it")
 	flag.Parse()
 
+	if *flagOutput != "text" && *flagOutput != "jsonl" {
+		log.Fatal("invalid --output", "err", fmt.Errorf("unknown format %q (allowed: text, jsonl)", *flagOutput))
+	}
+	jsonl := *flagOutput == "jsonl"
+	if jsonl && !*flagYes {
+		log.Fatal("--output=jsonl requires --yes, since prompts would corrupt the event stream")
+	}
+
 	if *flagVersion {
 		log.Infof("create-ekko-app %s", version)
 		return
@@ -32,29 +80,210 @@ func main() {
 	logger := log.New(os.Stderr)
 	logger.SetPrefix("create-ekko-app")
 
-	initial := options.Config{
-		Framework: options.FrameworkNext,
-		Auth:      options.AuthNone,
-		Database:  options.DatabaseNone,
-		Tooling:   []options.ToolingOption{},
+	defaults := options.Config{
+		Framework:      options.FrameworkNext,
+		Auth:           options.AuthNone,
+		Database:       options.DatabaseNone,
+		Tooling:        []options.ToolingOption{},
+		PackageManager: options.DetectPackageManager(),
 	}
 
-	if arg := flag.Arg(0); arg != "" {
-		initial.ProjectName = arg
-	}
+	// initial only holds values the user picked explicitly, layered as
+	// preset < config file < flags. Defaults are applied by the form or, in
+	// non-interactive mode, right before validation.
+	var initial options.Config
 
-	selection, err := ui.Run(ctx, initial)
+	presetDir, err := options.UserConfigDir()
 	if err != nil {
-		if errors.Is(err, ui.ErrAborted) {
-			logger.Info("setup cancelled")
-			return
+		logger.Warn("user presets unavailable", "err", err)
+	}
+	presets, err := options.LoadPresets(presetDir)
+	if err != nil {
+		// A broken user presets file only matters when a preset was asked
+		// for, since it may be the one defining it.
+		if *flagPreset != "" {
+			logger.Fatal("invalid user presets", "err", err)
+		}
+		logger.Warn("ignoring invalid user presets", "err", err)
+		presets = options.BuiltinPresets()
+	}
+	if *flagPreset != "" {
+		preset, err := options.FindPreset(presets, *flagPreset)
+		if err != nil {
+			logger.Fatal("invalid --preset", "err", err)
+		}
+		initial = preset.Config
+		presets = nil
+	}
+
+	if *flagConfig != "" {
+		fromFile, err := options.LoadFile(*flagConfig)
+		if err != nil {
+			logger.Fatal("invalid --config", "err", err)
+		}
+		initial = options.Overlay(initial, fromFile)
+	}
+
+	var fromFlags options.Config
+	if *flagFramework != "" {
+		if fromFlags.Framework, err = options.ParseFramework(*flagFramework); err != nil {
+			logger.Fatal("invalid --framework", "err", err)
+		}
+	}
+	if *flagAuth != "" {
+		if fromFlags.Auth, err = options.ParseAuth(*flagAuth); err != nil {
+			logger.Fatal("invalid --auth", "err", err)
+		}
+	}
+	if *flagDatabase != "" {
+		if fromFlags.Database, err = options.ParseDatabase(*flagDatabase); err != nil {
+			logger.Fatal("invalid --database", "err", err)
+		}
+	}
+	if *flagTooling != "" {
+		if fromFlags.Tooling, err = options.ParseTooling(*flagTooling); err != nil {
+			logger.Fatal("invalid --tooling", "err", err)
+		}
+	}
+	if *flagShadcnColor != "" {
+		if fromFlags.ShadcnColor, err = options.ParseShadcnColor(*flagShadcnColor); err != nil {
+			logger.Fatal("invalid --shadcn-color", "err", err)
+		}
+	}
+	if *flagPackageManager != "" {
+		if fromFlags.PackageManager, err = options.ParsePackageManager(*flagPackageManager); err != nil {
+			logger.Fatal("invalid --package-manager", "err", err)
+		}
+	}
+	if *flagVersions != "" {
+		if fromFlags.Versions, err = options.LoadVersions(*flagVersions); err != nil {
+			logger.Fatal("invalid --versions", "err", err)
+		}
+	}
+	fromFlags.Latest = *flagLatest
+	fromFlags.SkipShadcnOps = *flagSkipShadcn
+	fromFlags.Force = *flagForce
+	if *flagDir != "" {
+		if err := options.ValidateDirectory(*flagDir); err != nil {
+			logger.Fatal("invalid --dir", "err", err)
+		}
+		fromFlags.Directory = *flagDir
+	}
+	fromFlags.ProjectName = flag.Arg(0)
+	initial = options.Overlay(initial, fromFlags)
+
+	selection := options.Overlay(defaults, initial)
+	if *flagYes {
+		if selection.ProjectName == "" {
+			selection.ProjectName = "ekko-app"
+		}
+		if err := selection.Validate(); err != nil {
+			logger.Fatal("invalid configuration", "err", err)
+		}
+	} else {
+		selection, err = ui.Run(ctx, initial, presets)
+		if err != nil {
+			if errors.Is(err, ui.ErrAborted) {
+				logger.Info("setup cancelled")
+				return
+			}
+			logger.Fatal("interactive setup failed", "err", err)
 		}
-		logger.Fatal("interactive setup failed", "err", err)
 	}
 
-	if err := scaffold.Run(ctx, selection, logger); err != nil {
+	if *flagEmitScript != "" {
+		if err := emitScript(selection, *flagEmitScript); err != nil {
+			logger.Fatal("emit script failed", "err", err)
+		}
+		if *flagEmitScript != "-" {
+			logger.Info("wrote setup script", "path", *flagEmitScript)
+		}
+	}
+
+	if flagDryRun != "" {
+		if err := printPlan(selection, string(flagDryRun)); err != nil {
+			logger.Fatal("dry run failed", "err", err)
+		}
+	}
+
+	if *flagEmitScript != "" || flagDryRun != "" {
+		return
+	}
+
+	runOpts := scaffold.RunOptions{
+		Interactive:      !*flagYes && isatty.IsTerminal(os.Stdin.Fd()),
+		CleanupOnFailure: *flagCleanup,
+		Plain:            *flagPlain || !isatty.IsTerminal(os.Stdout.Fd()),
+		LogFile:          *flagLogFile,
+	}
+	if jsonl {
+		runOpts.Events = os.Stdout
+	}
+	if err := scaffold.Run(ctx, selection, runOpts, logger); err != nil {
+		if errors.Is(err, scaffold.ErrInstallCancelled) {
+			logger.Info("setup cancelled")
+			os.Exit(130)
+		}
 		logger.Fatal("scaffold failed", "err", err)
 	}
 
 	logger.Info("done")
 }
+
+// dryRunFlag accepts both a bare --dry-run and --dry-run=<format>.
+type dryRunFlag string
+
+func (f *dryRunFlag) String() string { return string(*f) }
+
+func (f *dryRunFlag) Set(value string) error {
+	switch value {
+	case "true", "text":
+		*f = "text"
+	case "false":
+		*f = ""
+	case "json":
+		*f = "json"
+	default:
+		return fmt.Errorf("unknown format %q (allowed: text, json)", value)
+	}
+	return nil
+}
+
+func (f *dryRunFlag) IsBoolFlag() bool { return true }
+
+func printPlan(cfg options.Config, format string) error {
+	plan, err := buildPlan(cfg)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return plan.WriteJSON(os.Stdout)
+	}
+	return plan.WriteText(os.Stdout)
+}
+
+func emitScript(cfg options.Config, path string) error {
+	plan, err := buildPlan(cfg)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return plan.WriteScript(os.Stdout)
+	}
+
+	var b strings.Builder
+	if err := plan.WriteScript(&b); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o755)
+}
+
+func buildPlan(cfg options.Config) (scaffold.Plan, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return scaffold.Plan{}, fmt.Errorf("resolve working directory: %w", err)
+	}
+	return scaffold.BuildPlan(cfg, cwd)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"github.com/mattn/go-isatty"
+
+	"github.com/mikekenway/create-ekko-app/internal/scaffold"
+)
+
+// runResume implements `create-ekko-app resume [dir]` and returns the exit code.
+func runResume(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	flagCleanup := fs.Bool("cleanup-on-failure", false, "remove everything this run created if scaffolding fails or is cancelled")
+	flagPlain := fs.Bool("plain", false, "print install progress as plain lines instead of the interactive view (default when stdout is not a terminal)")
+	flagLogFile := fs.String("log-file", "", "append the full install log to `path` instead of .ekko/install.log in the project")
+	flagOutput := fs.String("output", "text", "install output format (text, jsonl); jsonl writes one JSON event per line to stdout")
+	fs.Parse(args)
+
+	if *flagOutput != "text" && *flagOutput != "jsonl" {
+		fmt.Fprintf(os.Stderr, "invalid --output: unknown format %q (allowed: text, jsonl)\n", *flagOutput)
+		return 2
+	}
+
+	dir := fs.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+
+	logger := log.New(os.Stderr)
+	logger.SetPrefix("create-ekko-app")
+
+	opts := scaffold.RunOptions{
+		Interactive:      isatty.IsTerminal(os.Stdin.Fd()),
+		CleanupOnFailure: *flagCleanup,
+		Plain:            *flagPlain || !isatty.IsTerminal(os.Stdout.Fd()),
+		LogFile:          *flagLogFile,
+	}
+	if *flagOutput == "jsonl" {
+		// Prompts would corrupt the event stream.
+		opts.Interactive = false
+		opts.Events = os.Stdout
+	}
+	if err := scaffold.Resume(ctx, dir, opts, logger); err != nil {
+		if errors.Is(err, scaffold.ErrInstallCancelled) {
+			logger.Info("setup cancelled")
+			return 130
+		}
+		logger.Error("resume failed", "err", err)
+		if errors.Is(err, scaffold.ErrNoCheckpoint) && fs.NArg() == 0 {
+			logger.Info("to scaffold a project named resume, run: create-ekko-app -- resume")
+		}
+		return 1
+	}
+
+	logger.Info("done")
+	return 0
+}
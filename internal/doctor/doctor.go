@@ -0,0 +1,227 @@
+// Package doctor verifies the tools the scaffolder shells out to.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// Status grades a single check.
+type Status int
+
+const (
+	StatusPass Status = iota
+	StatusWarn
+	StatusFail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusWarn:
+		return "warn"
+	case StatusFail:
+		return "fail"
+	default:
+		return "pass"
+	}
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+}
+
+// MinNodeVersion is the oldest Node.js release create-next-app supports.
+var MinNodeVersion = Version{20, 9, 0}
+
+// Checker runs the prerequisite checks. The zero value is not usable; use
+// New, or fill in both hooks in tests.
+type Checker struct {
+	// LookPath resolves a binary on PATH.
+	LookPath func(file string) (string, error)
+	// Output runs a command and returns its trimmed stdout.
+	Output func(ctx context.Context, name string, args ...string) (string, error)
+}
+
+// New returns a Checker backed by the real PATH.
+func New() *Checker {
+	return &Checker{
+		LookPath: exec.LookPath,
+		Output: func(ctx context.Context, name string, args ...string) (string, error) {
+			out, err := exec.CommandContext(ctx, name, args...).Output()
+			return strings.TrimSpace(string(out)), err
+		},
+	}
+}
+
+// Check runs every check for a scaffold that uses pm.
+func (c *Checker) Check(ctx context.Context, pm options.PackageManager) []Result {
+	if pm == "" {
+		pm = options.PackageManagerPnpm
+	}
+	results := []Result{c.checkPackageManager(ctx, pm)}
+	if runner := pm.Runner(); runner != string(pm) {
+		results = append(results, c.checkRunner(runner, pm))
+	}
+	return append(results,
+		c.checkNode(ctx),
+		c.checkOptional("git", "the project will not be initialized as a git repository"),
+		c.checkOptional("code", "the project will not be opened in VS Code automatically"),
+	)
+}
+
+// checkRunner checks the binary the scaffolders are run with when it is not
+// the package manager itself, e.g. npx, which some distributions package
+// separately from npm.
+func (c *Checker) checkRunner(runner string, pm options.PackageManager) Result {
+	if _, err := c.LookPath(runner); err != nil {
+		return Result{Name: runner, Status: StatusFail, Detail: fmt.Sprintf("not found on PATH (%s runs the scaffolders with it)", pm)}
+	}
+	return Result{Name: runner, Detail: "found"}
+}
+
+func (c *Checker) checkPackageManager(ctx context.Context, pm options.PackageManager) Result {
+	name := string(pm)
+	result := Result{Name: name}
+	if _, err := c.LookPath(name); err != nil {
+		result.Status = StatusFail
+		result.Detail = "not found on PATH"
+		return result
+	}
+
+	out, err := c.Output(ctx, name, "--version")
+	if err != nil {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("installed, but `%s --version` failed: %v", name, err)
+		return result
+	}
+	result.Detail = out
+	return result
+}
+
+func (c *Checker) checkNode(ctx context.Context) Result {
+	result := Result{Name: "node"}
+	if _, err := c.LookPath("node"); err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("not found on PATH (need >= %s)", MinNodeVersion)
+		return result
+	}
+
+	out, err := c.Output(ctx, "node", "--version")
+	if err != nil {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("`node --version` failed: %v", err)
+		return result
+	}
+
+	version, err := ParseVersion(out)
+	if err != nil {
+		result.Status = StatusWarn
+		result.Detail = fmt.Sprintf("could not parse version %q", out)
+		return result
+	}
+	if version.Less(MinNodeVersion) {
+		result.Status = StatusFail
+		result.Detail = fmt.Sprintf("%s is too old (need >= %s)", version, MinNodeVersion)
+		return result
+	}
+	result.Detail = version.String()
+	return result
+}
+
+func (c *Checker) checkOptional(name, consequence string) Result {
+	if _, err := c.LookPath(name); err != nil {
+		return Result{Name: name, Status: StatusWarn, Detail: "not found on PATH; " + consequence}
+	}
+	return Result{Name: name, Detail: "found"}
+}
+
+// HasFailures reports whether any result is a hard failure.
+func HasFailures(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Failures joins the hard failures into a single line.
+func Failures(results []Result) string {
+	var parts []string
+	for _, r := range results {
+		if r.Status == StatusFail {
+			parts = append(parts, fmt.Sprintf("%s: %s", r.Name, r.Detail))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// WriteTable prints results as an aligned status table.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tCHECK\tDETAIL")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\n", statusIcon(r.Status), r.Status, r.Name, r.Detail)
+	}
+	return tw.Flush()
+}
+
+func statusIcon(s Status) string {
+	switch s {
+	case StatusWarn:
+		return "!"
+	case StatusFail:
+		return "✗"
+	default:
+		return "✓"
+	}
+}
+
+// Version is a major.minor.patch triple.
+type Version [3]int
+
+// ParseVersion accepts strings such as "v20.9.0" or "9.12.1".
+func ParseVersion(value string) (Version, error) {
+	var v Version
+	trimmed := strings.TrimPrefix(strings.TrimSpace(value), "v")
+	parts := strings.SplitN(trimmed, ".", 3)
+	if len(parts) == 0 || parts[0] == "" {
+		return v, fmt.Errorf("invalid version %q", value)
+	}
+	for i, part := range parts {
+		// Drop pre-release and build suffixes such as "1-rc.0".
+		if idx := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); idx >= 0 {
+			part = part[:idx]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, fmt.Errorf("invalid version %q", value)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// Less reports whether v sorts before other.
+func (v Version) Less(other Version) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
@@ -0,0 +1,137 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+func fakeChecker(installed map[string]string) *Checker {
+	return &Checker{
+		LookPath: func(file string) (string, error) {
+			if _, ok := installed[file]; ok {
+				return "/usr/bin/" + file, nil
+			}
+			return "", errors.New("not found")
+		},
+		Output: func(_ context.Context, name string, _ ...string) (string, error) {
+			return installed[name], nil
+		},
+	}
+}
+
+func TestCheckAllPresent(t *testing.T) {
+	c := fakeChecker(map[string]string{
+		"pnpm": "9.12.1",
+		"node": "v22.3.0",
+		"git":  "git version 2.45.0",
+		"code": "1.95.0",
+	})
+
+	results := c.Check(context.Background(), options.PackageManagerPnpm)
+	for _, r := range results {
+		if r.Status != StatusPass {
+			t.Fatalf("expected %s to pass, got %s (%s)", r.Name, r.Status, r.Detail)
+		}
+	}
+	if HasFailures(results) {
+		t.Fatal("expected no failures")
+	}
+}
+
+func TestCheckFailuresAndWarnings(t *testing.T) {
+	c := fakeChecker(map[string]string{
+		"node": "v18.17.0",
+	})
+
+	results := c.Check(context.Background(), options.PackageManagerYarn)
+	got := map[string]Status{}
+	for _, r := range results {
+		got[r.Name] = r.Status
+	}
+
+	want := map[string]Status{
+		"yarn": StatusFail,
+		"node": StatusFail,
+		"git":  StatusWarn,
+		"code": StatusWarn,
+	}
+	for name, status := range want {
+		if got[name] != status {
+			t.Fatalf("expected %s to be %s, got %s", name, status, got[name])
+		}
+	}
+
+	if !HasFailures(results) {
+		t.Fatal("expected failures")
+	}
+	if msg := Failures(results); !strings.Contains(msg, "node: 18.17.0 is too old (need >= 20.9.0)") {
+		t.Fatalf("unexpected failure summary %q", msg)
+	}
+
+	var table bytes.Buffer
+	if err := WriteTable(&table, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "✗ fail") || !strings.Contains(table.String(), "! warn") {
+		t.Fatalf("unexpected table:\n%s", table.String())
+	}
+}
+
+func TestCheckRunner(t *testing.T) {
+	installed := map[string]string{
+		"npm":  "10.8.0",
+		"node": "v22.3.0",
+	}
+
+	statuses := func() map[string]Status {
+		got := map[string]Status{}
+		for _, r := range fakeChecker(installed).Check(context.Background(), options.PackageManagerNpm) {
+			got[r.Name] = r.Status
+		}
+		return got
+	}
+
+	if got := statuses(); got["npm"] != StatusPass || got["npx"] != StatusFail {
+		t.Fatalf("expected a missing npx to fail next to npm, got %v", got)
+	}
+	installed["npx"] = ""
+	if got := statuses(); got["npx"] != StatusPass {
+		t.Fatalf("expected npx to pass once installed, got %v", got)
+	}
+
+	for _, r := range fakeChecker(installed).Check(context.Background(), options.PackageManagerPnpm) {
+		if r.Name == "npx" {
+			t.Fatalf("pnpm runs scaffolders itself; unexpected check %s", r.Name)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	cases := map[string]Version{
+		"v20.9.0":    {20, 9, 0},
+		"9.12.1":     {9, 12, 1},
+		"1.1.38-rc1": {1, 1, 38},
+		"22":         {22, 0, 0},
+	}
+	for in, want := range cases {
+		got, err := ParseVersion(in)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q): %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("ParseVersion(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	if _, err := ParseVersion("not-a-version"); err == nil {
+		t.Fatal("expected error for garbage input")
+	}
+	if !(Version{18, 20, 0}).Less(Version{20, 9, 0}) {
+		t.Fatal("expected 18.20.0 < 20.9.0")
+	}
+}
@@ -0,0 +1,172 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileError pinpoints a problem inside a config file.
+type FileError struct {
+	Path   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Msg)
+}
+
+// LoadFile reads a Config from a JSON or YAML file such as ekko.json or
+// ekko.yaml. Only the keys present in the file are set on the result.
+func LoadFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+	return DecodeFile(path, data)
+}
+
+// DecodeFile parses config file contents. JSON is decoded through the YAML
+// parser (JSON is valid YAML), so both formats report errors with line and
+// column numbers. path is only used to label errors.
+func DecodeFile(path string, data []byte) (Config, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return Config{}, fmt.Errorf("%s: config file is empty", path)
+	}
+
+	return decodeConfig(path, doc.Content[0], nil)
+}
+
+// decodeConfig reads config keys from a mapping node. extra, when non-nil,
+// gets first pick at every key and reports whether it consumed it.
+func decodeConfig(path string, node *yaml.Node, extra func(key, value *yaml.Node) (bool, error)) (Config, error) {
+	if node.Kind != yaml.MappingNode {
+		return Config{}, nodeError(path, node, "expected a mapping of config keys")
+	}
+
+	var cfg Config
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if seen[key.Value] {
+			return Config{}, nodeError(path, key, fmt.Sprintf("duplicate key %q", key.Value))
+		}
+		seen[key.Value] = true
+
+		if extra != nil {
+			handled, err := extra(key, value)
+			if err != nil {
+				return Config{}, nodeError(path, value, err.Error())
+			}
+			if handled {
+				continue
+			}
+		}
+
+		if bad, err := decodeField(&cfg, key, value); err != nil {
+			return Config{}, nodeError(path, bad, err.Error())
+		}
+	}
+
+	return cfg, nil
+}
+
+var fileKeys = []string{
+	"projectName",
+	"framework",
+	"auth",
+	"database",
+	"tooling",
+	"shadcnColor",
+	"skipShadcn",
+	"packageManager",
+	"directory",
+	"versions",
+	"latest",
+}
+
+// decodeField sets a single key on cfg. On failure it also returns the node
+// the error should point at.
+func decodeField(cfg *Config, key, value *yaml.Node) (*yaml.Node, error) {
+	var err error
+	switch key.Value {
+	case "projectName":
+		cfg.ProjectName, err = scalarString(value)
+	case "framework":
+		cfg.Framework, err = scalarEnum(value, ParseFramework)
+	case "auth":
+		cfg.Auth, err = scalarEnum(value, ParseAuth)
+	case "database":
+		cfg.Database, err = scalarEnum(value, ParseDatabase)
+	case "shadcnColor":
+		cfg.ShadcnColor, err = scalarEnum(value, ParseShadcnColor)
+	case "skipShadcn":
+		err = value.Decode(&cfg.SkipShadcnOps)
+		if err != nil {
+			err = errors.New("skipShadcn must be true or false")
+		}
+	case "directory":
+		cfg.Directory, err = scalarString(value)
+		if err == nil {
+			err = ValidateDirectory(cfg.Directory)
+		}
+	case "packageManager":
+		cfg.PackageManager, err = scalarEnum(value, ParsePackageManager)
+	case "latest":
+		err = value.Decode(&cfg.Latest)
+		if err != nil {
+			err = errors.New("latest must be true or false")
+		}
+	case "tooling":
+		return decodeTooling(cfg, value)
+	case "versions":
+		cfg.Versions, value, err = decodeVersions(value)
+	default:
+		return key, fmt.Errorf("unknown key %q (allowed: %s)", key.Value, strings.Join(fileKeys, ", "))
+	}
+	return value, err
+}
+
+func decodeTooling(cfg *Config, value *yaml.Node) (*yaml.Node, error) {
+	if value.Kind != yaml.SequenceNode {
+		return value, errors.New("tooling must be a list")
+	}
+	tools := []ToolingOption{}
+	for _, item := range value.Content {
+		tool, err := scalarEnum(item, ParseToolingOption)
+		if err != nil {
+			return item, err
+		}
+		tools = append(tools, tool)
+	}
+	cfg.Tooling = tools
+	return nil, nil
+}
+
+func scalarString(value *yaml.Node) (string, error) {
+	if value.Kind != yaml.ScalarNode {
+		return "", errors.New("expected a string")
+	}
+	return value.Value, nil
+}
+
+func scalarEnum[T ~string](value *yaml.Node, parse func(string) (T, error)) (T, error) {
+	raw, err := scalarString(value)
+	if err != nil {
+		return "", err
+	}
+	return parse(raw)
+}
+
+func nodeError(path string, node *yaml.Node, msg string) error {
+	return &FileError{Path: path, Line: node.Line, Column: node.Column, Msg: msg}
+}
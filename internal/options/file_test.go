@@ -0,0 +1,146 @@
+package options
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestDecodeFileYAML(t *testing.T) {
+	data := []byte(`
+projectName: demo
+framework: tanstack-start
+auth: clerk
+tooling:
+  - shadcn
+  - resend
+shadcnColor: slate
+skipShadcn: true
+latest: true
+versions:
+  create-next-app: 15.5.4
+  "@clerk/nextjs": ^6.33.0
+`)
+	cfg, err := DecodeFile("ekko.yaml", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ProjectName != "demo" || cfg.Framework != FrameworkTanstackStart || cfg.Auth != AuthClerk {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Database != "" {
+		t.Fatalf("expected database to stay unset, got %q", cfg.Database)
+	}
+	if !slices.Equal(cfg.Tooling, []ToolingOption{ToolShadcn, ToolResend}) {
+		t.Fatalf("unexpected tooling: %v", cfg.Tooling)
+	}
+	if cfg.ShadcnColor != "slate" || !cfg.SkipShadcnOps {
+		t.Fatalf("unexpected shadcn settings: %+v", cfg)
+	}
+	wantVersions := map[string]string{"create-next-app": "15.5.4", "@clerk/nextjs": "^6.33.0"}
+	if !cfg.Latest || !maps.Equal(cfg.Versions, wantVersions) {
+		t.Fatalf("unexpected version settings: latest=%v versions=%v", cfg.Latest, cfg.Versions)
+	}
+}
+
+func TestDecodeFileJSON(t *testing.T) {
+	data := []byte(`{
+  "framework": "next",
+  "database": "drizzle",
+  "tooling": []
+}`)
+	cfg, err := DecodeFile("ekko.json", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Framework != FrameworkNext || cfg.Database != DatabaseDrizzle {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Tooling == nil || len(cfg.Tooling) != 0 {
+		t.Fatalf("expected explicit empty tooling, got %#v", cfg.Tooling)
+	}
+}
+
+func TestDecodeFileErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		line int
+		col  int
+	}{
+		{
+			name: "unknown key",
+			data: "framework: next\ndatabse: drizzle\n",
+			line: 2,
+			col:  1,
+		},
+		{
+			name: "unknown enum",
+			data: "framework: next\nauth: auth0\n",
+			line: 2,
+			col:  7,
+		},
+		{
+			name: "unknown tooling entry",
+			data: "tooling:\n  - shadcn\n  - prisma\n",
+			line: 3,
+			col:  5,
+		},
+		{
+			name: "version with spaces",
+			data: "versions:\n  clsx: 2.1.1 || 3\n",
+			line: 2,
+			col:  9,
+		},
+		{
+			name: "unknown key in json",
+			data: "{\n  \"framework\": \"next\",\n  \"colour\": \"zinc\"\n}",
+			line: 3,
+			col:  3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeFile("ekko.yaml", []byte(tc.data))
+			var fileErr *FileError
+			if !errors.As(err, &fileErr) {
+				t.Fatalf("expected FileError, got %v", err)
+			}
+			if fileErr.Line != tc.line || fileErr.Column != tc.col {
+				t.Fatalf("expected %d:%d, got %d:%d (%s)", tc.line, tc.col, fileErr.Line, fileErr.Column, fileErr)
+			}
+		})
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	base := Config{
+		ProjectName: "base",
+		Framework:   FrameworkNext,
+		Auth:        AuthNone,
+		Tooling:     []ToolingOption{ToolShadcn},
+	}
+	got := Overlay(base, Config{Auth: AuthClerk})
+	if got.ProjectName != "base" || got.Auth != AuthClerk {
+		t.Fatalf("unexpected overlay: %+v", got)
+	}
+	if !slices.Equal(got.Tooling, base.Tooling) {
+		t.Fatalf("expected tooling to be kept, got %v", got.Tooling)
+	}
+
+	got = Overlay(base, Config{Tooling: []ToolingOption{}})
+	if len(got.Tooling) != 0 {
+		t.Fatalf("expected tooling to be cleared, got %v", got.Tooling)
+	}
+
+	base.Versions = map[string]string{"clsx": "2.1.1", "resend": "6.1.2"}
+	got = Overlay(base, Config{Versions: map[string]string{"resend": "6.0.0"}})
+	if want := map[string]string{"clsx": "2.1.1", "resend": "6.0.0"}; !maps.Equal(got.Versions, want) {
+		t.Fatalf("expected versions to be merged, got %v", got.Versions)
+	}
+	if base.Versions["resend"] != "6.1.2" {
+		t.Fatal("Overlay must not modify the base versions")
+	}
+}
@@ -0,0 +1,159 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxProjectNameLength is npm's limit on package name length.
+const maxProjectNameLength = 214
+
+// ValidateProjectName checks name against npm's package-name rules: at most
+// 214 characters, lowercase, URL-safe, no leading dot or underscore, and an
+// optional @scope/ prefix. Because the name doubles as the project directory,
+// anything that could escape the working directory is rejected as well.
+func ValidateProjectName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("project name is required")
+	}
+	if strings.TrimSpace(name) != name {
+		return errors.New("project name must not start or end with spaces")
+	}
+	if len(name) > maxProjectNameLength {
+		return fmt.Errorf("project name must be at most %d characters", maxProjectNameLength)
+	}
+
+	pkg := name
+	if strings.HasPrefix(name, "@") {
+		scope, rest, ok := strings.Cut(name[1:], "/")
+		if !ok || strings.Contains(rest, "/") {
+			return errors.New("scoped project names must look like @scope/name")
+		}
+		if err := validateNamePart("scope", scope); err != nil {
+			return err
+		}
+		pkg = rest
+	}
+
+	return validateNamePart("project name", pkg)
+}
+
+func validateNamePart(label, part string) error {
+	switch {
+	case part == "":
+		return fmt.Errorf("%s must not be empty", label)
+	case strings.ContainsAny(part, `/\`):
+		return fmt.Errorf("%s must not contain path separators", label)
+	case part == "." || part == "..":
+		return fmt.Errorf("%s must not be a relative path", label)
+	case strings.HasPrefix(part, "."), strings.HasPrefix(part, "_"):
+		return fmt.Errorf("%s must not start with %q", label, part[:1])
+	case part == "node_modules" || part == "favicon.ico":
+		return fmt.Errorf("%s %q is reserved", label, part)
+	}
+
+	for _, r := range part {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+		case r >= 'A' && r <= 'Z':
+			return fmt.Errorf("%s must be lowercase", label)
+		case r == ' ':
+			return fmt.Errorf("%s must not contain spaces", label)
+		default:
+			return fmt.Errorf("%s must not contain %q", label, r)
+		}
+	}
+	return nil
+}
+
+// ValidateDirectory checks that dir is a relative path that stays inside the
+// working directory, such as "apps/web".
+func ValidateDirectory(dir string) error {
+	if strings.TrimSpace(dir) == "" {
+		return errors.New("directory must not be empty")
+	}
+	if filepath.IsAbs(dir) {
+		return errors.New("directory must be relative to the current directory")
+	}
+	clean := filepath.Clean(dir)
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return errors.New("directory must stay inside the current directory")
+	}
+	return nil
+}
+
+// scaffoldKeeps lists the entries create-next-app accepts in the directory
+// it scaffolds into; anything else makes it refuse. Editor project files
+// (*.iml) are accepted too.
+var scaffoldKeeps = []string{
+	".DS_Store", ".git", ".gitattributes", ".gitignore", ".gitlab-ci.yml",
+	".hg", ".hgcheck", ".hgignore", ".idea", ".npmignore", ".travis.yml",
+	".yarn", "LICENSE", "Thumbs.db", "docs", "mkdocs.yml", "npm-debug.log",
+	"yarn-debug.log", "yarn-error.log", "yarnrc.yml",
+}
+
+// ScaffoldConflicts returns the entries of path the framework scaffolders
+// refuse to scaffold next to, sorted. It is empty when path is missing or
+// only holds repository files such as .git or LICENSE; when path is a file,
+// it is the file's name.
+func ScaffoldConflicts(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{filepath.Base(path)}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !slices.Contains(scaffoldKeeps, name) && !strings.HasSuffix(name, ".iml") {
+			conflicts = append(conflicts, name)
+		}
+	}
+	return conflicts, nil
+}
+
+// DirectoryInUse reports whether path exists and is either a file or a
+// non-empty directory, i.e. whether scaffolding into it could clobber
+// something.
+func DirectoryInUse(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return true, nil
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer dir.Close()
+
+	_, err = dir.Readdirnames(1)
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateProjectName(t *testing.T) {
+	valid := []string{"ekko-app", "my.app", "app_2", "@acme/web", "a"}
+	for _, name := range valid {
+		if err := ValidateProjectName(name); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", name, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                       "required",
+		"My-App":                 "lowercase",
+		"my app":                 "spaces",
+		" app":                   "spaces",
+		".hidden":                "must not start",
+		"_private":               "must not start",
+		"../escape":              "path separators",
+		"..":                     "relative path",
+		"apps/web":               "path separators",
+		`apps\web`:               "path separators",
+		"@acme":                  "@scope/name",
+		"@acme/web/extra":        "@scope/name",
+		"@../web":                "relative path",
+		"node_modules":           "reserved",
+		"cool!":                  "must not contain",
+		strings.Repeat("a", 215): "at most 214",
+	}
+	for name, want := range invalid {
+		err := ValidateProjectName(name)
+		if err == nil {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error for %q to mention %q, got %v", name, want, err)
+		}
+	}
+}
+
+func TestDirectoryInUse(t *testing.T) {
+	root := t.TempDir()
+
+	if inUse, err := DirectoryInUse(filepath.Join(root, "missing")); err != nil || inUse {
+		t.Fatalf("missing dir: inUse=%v err=%v", inUse, err)
+	}
+
+	empty := filepath.Join(root, "empty")
+	if err := os.Mkdir(empty, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if inUse, err := DirectoryInUse(empty); err != nil || inUse {
+		t.Fatalf("empty dir: inUse=%v err=%v", inUse, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(empty, "README.md"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if inUse, err := DirectoryInUse(empty); err != nil || !inUse {
+		t.Fatalf("non-empty dir: inUse=%v err=%v", inUse, err)
+	}
+
+	if inUse, err := DirectoryInUse(filepath.Join(empty, "README.md")); err != nil || !inUse {
+		t.Fatalf("file: inUse=%v err=%v", inUse, err)
+	}
+}
+
+func TestScaffoldConflicts(t *testing.T) {
+	root := t.TempDir()
+	if conflicts, err := ScaffoldConflicts(filepath.Join(root, "missing")); err != nil || len(conflicts) > 0 {
+		t.Fatalf("missing dir: conflicts=%v err=%v", conflicts, err)
+	}
+
+	for _, name := range []string{".git", "LICENSE", ".gitignore", "web.iml"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if conflicts, err := ScaffoldConflicts(root); err != nil || len(conflicts) > 0 {
+		t.Fatalf("repository files must be accepted: conflicts=%v err=%v", conflicts, err)
+	}
+
+	for _, name := range []string{"package.json", "README.md"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conflicts, err := ScaffoldConflicts(root)
+	if err != nil || !slices.Equal(conflicts, []string{"README.md", "package.json"}) {
+		t.Fatalf("conflicts=%v err=%v", conflicts, err)
+	}
+
+	if conflicts, err := ScaffoldConflicts(filepath.Join(root, "LICENSE")); err != nil || !slices.Equal(conflicts, []string{"LICENSE"}) {
+		t.Fatalf("file: conflicts=%v err=%v", conflicts, err)
+	}
+}
+
+func TestValidateDirectory(t *testing.T) {
+	for _, dir := range []string{"apps/web", "./apps/web", "web", "."} {
+		if err := ValidateDirectory(dir); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", dir, err)
+		}
+	}
+	for _, dir := range []string{"", "/abs/path", "..", "../sibling", "apps/../../escape"} {
+		if err := ValidateDirectory(dir); err == nil {
+			t.Fatalf("expected %q to be rejected", dir)
+		}
+	}
+}
+
+func TestTargetDir(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{ProjectName: "web"}, "web"},
+		{Config{ProjectName: "@acme/web"}, "web"},
+		{Config{ProjectName: "@acme/web", Directory: "./apps/web/"}, filepath.Join("apps", "web")},
+	}
+	for _, tc := range cases {
+		if got := tc.cfg.TargetDir(); got != tc.want {
+			t.Fatalf("TargetDir(%+v) = %q, want %q", tc.cfg, got, tc.want)
+		}
+	}
+}
@@ -1,5 +1,14 @@
 package options
 
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
 // Framework identifies the target application scaffold.
 type Framework string
 
@@ -37,13 +46,260 @@ const (
 	ToolResend        ToolingOption = "resend"
 )
 
+// PackageManager selects the Node.js package manager used for every step.
+type PackageManager string
+
+const (
+	PackageManagerPnpm PackageManager = "pnpm"
+	PackageManagerNpm  PackageManager = "npm"
+	PackageManagerYarn PackageManager = "yarn"
+	PackageManagerBun  PackageManager = "bun"
+)
+
+// PackageManagerEnv is set by the npm launcher (cli.mjs) to the package
+// manager that invoked it, e.g. "yarn" for `yarn create ekko-app`.
+const PackageManagerEnv = "CREATE_EKKO_APP_PACKAGE_MANAGER"
+
+// DetectPackageManager returns the package manager reported by the launcher,
+// falling back to pnpm.
+func DetectPackageManager() PackageManager {
+	if pm, err := ParsePackageManager(os.Getenv(PackageManagerEnv)); err == nil {
+		return pm
+	}
+	return PackageManagerPnpm
+}
+
+// Runner returns the binary that runs a package without installing it:
+// npx for npm, bunx for Bun, and the package manager itself (pnpm dlx, yarn
+// dlx) otherwise. An empty PackageManager means pnpm.
+func (pm PackageManager) Runner() string {
+	switch pm {
+	case PackageManagerNpm:
+		return "npx"
+	case PackageManagerBun:
+		return "bunx"
+	case "":
+		return string(PackageManagerPnpm)
+	default:
+		return string(pm)
+	}
+}
+
 // Config mirrors the interactive selections made by the user.
 type Config struct {
-	ProjectName   string
-	Framework     Framework
-	Auth          AuthChoice
-	Database      DatabaseChoice
-	Tooling       []ToolingOption
-	ShadcnColor   string
-	SkipShadcnOps bool
+	ProjectName   string          `json:"projectName,omitempty"`
+	Framework     Framework       `json:"framework,omitempty"`
+	Auth          AuthChoice      `json:"auth,omitempty"`
+	Database      DatabaseChoice  `json:"database,omitempty"`
+	Tooling       []ToolingOption `json:"tooling,omitempty"`
+	ShadcnColor   string          `json:"shadcnColor,omitempty"`
+	SkipShadcnOps bool            `json:"skipShadcn,omitempty"`
+	// PackageManager defaults to pnpm when empty.
+	PackageManager PackageManager `json:"packageManager,omitempty"`
+	// Directory is the project path relative to the working directory. It
+	// defaults to the project name without its @scope/ prefix.
+	Directory string `json:"directory,omitempty"`
+	// Versions pins npm packages, the scaffolders included, by name. Entries
+	// override the manifest built into the CLI.
+	Versions map[string]string `json:"versions,omitempty"`
+	// Latest ignores every pin and installs the latest release of each
+	// package instead.
+	Latest bool `json:"latest,omitempty"`
+	// Force allows scaffolding into an existing, non-empty directory, as
+	// long as it only holds files the scaffolders accept (see
+	// ScaffoldConflicts).
+	Force bool `json:"-"`
+}
+
+// Overlay returns base with every field that is set on over applied on top.
+// A nil Tooling slice on over leaves the base tooling untouched, while an
+// empty one clears it. Versions are merged package by package.
+func Overlay(base, over Config) Config {
+	out := base
+	if over.ProjectName != "" {
+		out.ProjectName = over.ProjectName
+	}
+	if over.Framework != "" {
+		out.Framework = over.Framework
+	}
+	if over.Auth != "" {
+		out.Auth = over.Auth
+	}
+	if over.Database != "" {
+		out.Database = over.Database
+	}
+	if over.Tooling != nil {
+		out.Tooling = slices.Clone(over.Tooling)
+	}
+	if over.ShadcnColor != "" {
+		out.ShadcnColor = over.ShadcnColor
+	}
+	if over.SkipShadcnOps {
+		out.SkipShadcnOps = true
+	}
+	if over.PackageManager != "" {
+		out.PackageManager = over.PackageManager
+	}
+	if over.Directory != "" {
+		out.Directory = over.Directory
+	}
+	if len(over.Versions) > 0 {
+		out.Versions = maps.Clone(base.Versions)
+		if out.Versions == nil {
+			out.Versions = map[string]string{}
+		}
+		maps.Copy(out.Versions, over.Versions)
+	}
+	if over.Latest {
+		out.Latest = true
+	}
+	if over.Force {
+		out.Force = true
+	}
+	return out
+}
+
+// Frameworks lists every supported Framework in display order.
+var Frameworks = []Framework{FrameworkNext, FrameworkTanstackStart}
+
+// AuthChoices lists every supported AuthChoice in display order.
+var AuthChoices = []AuthChoice{AuthNone, AuthClerk, AuthBetterAuth}
+
+// DatabaseChoices lists every supported DatabaseChoice in display order.
+var DatabaseChoices = []DatabaseChoice{DatabaseNone, DatabaseConvex, DatabaseDrizzle}
+
+// ToolingOptions lists every supported ToolingOption in display order.
+var ToolingOptions = []ToolingOption{
+	ToolTanstackQuery,
+	ToolTanstackForm,
+	ToolShadcn,
+	ToolReactEmail,
+	ToolResend,
+}
+
+// PackageManagers lists every supported PackageManager in display order.
+var PackageManagers = []PackageManager{
+	PackageManagerPnpm,
+	PackageManagerNpm,
+	PackageManagerYarn,
+	PackageManagerBun,
+}
+
+// ShadcnColors lists the base colors accepted by shadcn init.
+var ShadcnColors = []string{"neutral", "gray", "zinc", "stone", "slate"}
+
+// ParseFramework converts a raw value into a Framework.
+func ParseFramework(value string) (Framework, error) {
+	return parseEnum("framework", value, Frameworks)
+}
+
+// ParseAuth converts a raw value into an AuthChoice.
+func ParseAuth(value string) (AuthChoice, error) {
+	return parseEnum("auth", value, AuthChoices)
+}
+
+// ParseDatabase converts a raw value into a DatabaseChoice.
+func ParseDatabase(value string) (DatabaseChoice, error) {
+	return parseEnum("database", value, DatabaseChoices)
+}
+
+// ParseToolingOption converts a raw value into a ToolingOption.
+func ParseToolingOption(value string) (ToolingOption, error) {
+	return parseEnum("tooling option", value, ToolingOptions)
+}
+
+// ParseTooling converts a comma-separated list into tooling options.
+// Blank entries are ignored and duplicates are dropped.
+func ParseTooling(value string) ([]ToolingOption, error) {
+	out := []ToolingOption{}
+	for _, raw := range strings.Split(value, ",") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		tool, err := ParseToolingOption(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(out, tool) {
+			out = append(out, tool)
+		}
+	}
+	return out, nil
+}
+
+// ParsePackageManager converts a raw value into a PackageManager.
+func ParsePackageManager(value string) (PackageManager, error) {
+	return parseEnum("package manager", value, PackageManagers)
+}
+
+// ParseShadcnColor validates a shadcn base color.
+func ParseShadcnColor(value string) (string, error) {
+	return parseEnum("shadcn color", value, ShadcnColors)
+}
+
+// Validate reports the first field that holds an unsupported value.
+func (c Config) Validate() error {
+	if err := ValidateProjectName(c.ProjectName); err != nil {
+		return err
+	}
+	if _, err := ParseFramework(string(c.Framework)); err != nil {
+		return err
+	}
+	if _, err := ParseAuth(string(c.Auth)); err != nil {
+		return err
+	}
+	if _, err := ParseDatabase(string(c.Database)); err != nil {
+		return err
+	}
+	for _, tool := range c.Tooling {
+		if _, err := ParseToolingOption(string(tool)); err != nil {
+			return err
+		}
+	}
+	if c.ShadcnColor != "" {
+		if _, err := ParseShadcnColor(c.ShadcnColor); err != nil {
+			return err
+		}
+	}
+	if c.PackageManager != "" {
+		if _, err := ParsePackageManager(string(c.PackageManager)); err != nil {
+			return err
+		}
+	}
+	if c.Directory != "" {
+		if err := ValidateDirectory(c.Directory); err != nil {
+			return err
+		}
+	}
+	return ValidateVersions(c.Versions)
+}
+
+// TargetDir returns the project directory relative to the working
+// directory: Directory when set, otherwise the unscoped project name.
+func (c Config) TargetDir() string {
+	if c.Directory != "" {
+		return filepath.Clean(c.Directory)
+	}
+	if _, name, ok := strings.Cut(c.ProjectName, "/"); ok && strings.HasPrefix(c.ProjectName, "@") {
+		return name
+	}
+	return c.ProjectName
+}
+
+func parseEnum[T ~string](kind, value string, allowed []T) (T, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	for _, candidate := range allowed {
+		if string(candidate) == normalized {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("unknown %s %q (allowed: %s)", kind, value, joinValues(allowed))
+}
+
+func joinValues[T ~string](values []T) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = string(v)
+	}
+	return strings.Join(parts, ", ")
 }
@@ -0,0 +1,86 @@
+package options
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFramework(t *testing.T) {
+	got, err := ParseFramework("TanStack-Start")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != FrameworkTanstackStart {
+		t.Fatalf("expected %s, got %s", FrameworkTanstackStart, got)
+	}
+
+	_, err = ParseFramework("remix")
+	if err == nil {
+		t.Fatal("expected error for unknown framework")
+	}
+	if !strings.Contains(err.Error(), "allowed: next, tanstack-start") {
+		t.Fatalf("expected allowed values in error, got %q", err)
+	}
+}
+
+func TestParseTooling(t *testing.T) {
+	got, err := ParseTooling("shadcn, resend,,shadcn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []ToolingOption{ToolShadcn, ToolResend}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected tooling: %v", got)
+	}
+
+	if _, err := ParseTooling("shadcn,prisma"); err == nil {
+		t.Fatal("expected error for unknown tooling option")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{
+		ProjectName: "demo",
+		Framework:   FrameworkNext,
+		Auth:        AuthClerk,
+		Database:    DatabaseDrizzle,
+		Tooling:     []ToolingOption{ToolShadcn},
+		ShadcnColor: "slate",
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.Database = "mongo"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "unknown database") {
+		t.Fatalf("expected unknown database error, got %v", err)
+	}
+}
+
+func TestDetectPackageManager(t *testing.T) {
+	t.Setenv(PackageManagerEnv, "yarn")
+	if got := DetectPackageManager(); got != PackageManagerYarn {
+		t.Fatalf("expected yarn, got %s", got)
+	}
+
+	t.Setenv(PackageManagerEnv, "")
+	if got := DetectPackageManager(); got != PackageManagerPnpm {
+		t.Fatalf("expected pnpm fallback, got %s", got)
+	}
+}
+
+func TestPackageManagerRunner(t *testing.T) {
+	want := map[PackageManager]string{
+		"":                 "pnpm",
+		PackageManagerPnpm: "pnpm",
+		PackageManagerNpm:  "npx",
+		PackageManagerYarn: "yarn",
+		PackageManagerBun:  "bunx",
+	}
+	for pm, runner := range want {
+		if got := pm.Runner(); got != runner {
+			t.Fatalf("%q.Runner() = %q, want %q", pm, got, runner)
+		}
+	}
+}
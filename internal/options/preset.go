@@ -0,0 +1,162 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Preset is a named, reusable starting point for the selections. Presets
+// pre-fill the form; every field can still be changed afterwards.
+type Preset struct {
+	Name        string
+	Description string
+	Config      Config
+}
+
+// BuiltinPresets returns the presets that ship with the CLI.
+func BuiltinPresets() []Preset {
+	return []Preset{
+		{
+			Name:        "saas",
+			Description: "Next.js, Clerk, Drizzle, shadcn and TanStack Query",
+			Config: Config{
+				Framework: FrameworkNext,
+				Auth:      AuthClerk,
+				Database:  DatabaseDrizzle,
+				Tooling:   []ToolingOption{ToolShadcn, ToolTanstackQuery},
+			},
+		},
+		{
+			Name:        "marketing",
+			Description: "Next.js, shadcn, React Email and Resend",
+			Config: Config{
+				Framework: FrameworkNext,
+				Auth:      AuthNone,
+				Database:  DatabaseNone,
+				Tooling:   []ToolingOption{ToolShadcn, ToolReactEmail, ToolResend},
+			},
+		},
+	}
+}
+
+// UserConfigDir returns the directory holding user-level settings:
+// $XDG_CONFIG_HOME/create-ekko-app, falling back to ~/.config/create-ekko-app.
+func UserConfigDir() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve config directory: %w", err)
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "create-ekko-app"), nil
+}
+
+// presetFiles are the file names checked, in order, for user presets.
+var presetFiles = []string{"presets.yaml", "presets.yml", "presets.json"}
+
+// LoadPresets returns the built-in presets followed by any user presets found
+// in dir. A user preset with the same name as a built-in one replaces it.
+//
+// The user file maps preset names to config keys plus an optional
+// description:
+//
+//	internal-tool:
+//	  description: Dashboard with auth and a database
+//	  framework: next
+//	  auth: better-auth
+//	  database: drizzle
+func LoadPresets(dir string) ([]Preset, error) {
+	presets := BuiltinPresets()
+	if dir == "" {
+		return presets, nil
+	}
+
+	for _, name := range presetFiles {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read presets: %w", err)
+		}
+
+		user, err := DecodePresets(path, data)
+		if err != nil {
+			return nil, err
+		}
+		for _, preset := range user {
+			idx := slices.IndexFunc(presets, func(p Preset) bool { return p.Name == preset.Name })
+			if idx >= 0 {
+				presets[idx] = preset
+			} else {
+				presets = append(presets, preset)
+			}
+		}
+		break
+	}
+
+	return presets, nil
+}
+
+// DecodePresets parses a user presets file. path is only used to label errors.
+func DecodePresets(path string, data []byte) ([]Preset, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nodeError(path, root, "expected a mapping of preset names")
+	}
+
+	var presets []Preset
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if slices.ContainsFunc(presets, func(p Preset) bool { return p.Name == key.Value }) {
+			return nil, nodeError(path, key, fmt.Sprintf("duplicate preset %q", key.Value))
+		}
+
+		preset := Preset{Name: key.Value}
+		cfg, err := decodeConfig(path, value, func(k, v *yaml.Node) (bool, error) {
+			if k.Value != "description" {
+				return false, nil
+			}
+			desc, err := scalarString(v)
+			preset.Description = desc
+			return true, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		preset.Config = cfg
+		presets = append(presets, preset)
+	}
+
+	return presets, nil
+}
+
+// FindPreset looks up a preset by name.
+func FindPreset(presets []Preset, name string) (Preset, error) {
+	for _, preset := range presets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	names := make([]string, len(presets))
+	for i, preset := range presets {
+		names[i] = preset.Name
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, joinValues(names))
+}
@@ -0,0 +1,73 @@
+package options
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadPresetsMergesUserFile(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte(`
+saas:
+  description: Our take on SaaS
+  framework: tanstack-start
+  auth: better-auth
+internal:
+  description: Internal dashboard
+  database: convex
+  tooling: [tanstack-form]
+`)
+	if err := os.WriteFile(filepath.Join(dir, "presets.yaml"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	presets, err := LoadPresets(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saas, err := FindPreset(presets, "saas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saas.Description != "Our take on SaaS" || saas.Config.Framework != FrameworkTanstackStart {
+		t.Fatalf("expected user preset to replace built-in, got %+v", saas)
+	}
+
+	internal, err := FindPreset(presets, "internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if internal.Config.Database != DatabaseConvex || !slices.Equal(internal.Config.Tooling, []ToolingOption{ToolTanstackForm}) {
+		t.Fatalf("unexpected user preset: %+v", internal)
+	}
+
+	if _, err := FindPreset(presets, "marketing"); err != nil {
+		t.Fatalf("expected built-in marketing preset to remain: %v", err)
+	}
+}
+
+func TestDecodePresetsReportsLocation(t *testing.T) {
+	_, err := DecodePresets("presets.yaml", []byte("mine:\n  framework: remix\n"))
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) {
+		t.Fatalf("expected FileError, got %v", err)
+	}
+	if fileErr.Line != 2 || fileErr.Column != 14 {
+		t.Fatalf("unexpected location %d:%d", fileErr.Line, fileErr.Column)
+	}
+}
+
+func TestUserConfigDirHonorsXDG(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	dir, err := UserConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join("/tmp/xdg", "create-ekko-app") {
+		t.Fatalf("unexpected dir %s", dir)
+	}
+}
@@ -0,0 +1,103 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// npmPackageName matches an npm package name, scoped or not.
+var npmPackageName = regexp.MustCompile(`^(@[a-z0-9~-][a-z0-9._~-]*/)?[a-z0-9~-][a-z0-9._~-]*$`)
+
+// LoadVersions reads a versions file, as passed to --versions: a JSON or
+// YAML mapping of npm package names to the version, range or dist tag to
+// install, for example
+//
+//	create-next-app: 15.5.4
+//	"@clerk/nextjs": 6.33.1
+func LoadVersions(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read versions: %w", err)
+	}
+	return DecodeVersions(path, data)
+}
+
+// DecodeVersions parses versions file contents. path is only used to label
+// errors.
+func DecodeVersions(path string, data []byte) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s: versions file is empty", path)
+	}
+
+	versions, bad, err := decodeVersions(doc.Content[0])
+	if err != nil {
+		return nil, nodeError(path, bad, err.Error())
+	}
+	return versions, nil
+}
+
+// decodeVersions reads a mapping of package names to versions. On failure it
+// also returns the node the error should point at.
+func decodeVersions(node *yaml.Node) (map[string]string, *yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, node, errors.New("versions must map package names to versions")
+	}
+	versions := make(map[string]string, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if _, ok := versions[key.Value]; ok {
+			return nil, key, fmt.Errorf("duplicate package %q", key.Value)
+		}
+		if err := validatePackageName(key.Value); err != nil {
+			return nil, key, err
+		}
+		version, err := scalarString(value)
+		if err == nil {
+			err = validateVersion(key.Value, version)
+		}
+		if err != nil {
+			return nil, value, err
+		}
+		versions[key.Value] = version
+	}
+	return versions, nil, nil
+}
+
+// ValidateVersions checks that every entry names an npm package and a
+// version that can be appended to it on a command line.
+func ValidateVersions(versions map[string]string) error {
+	for _, name := range slices.Sorted(maps.Keys(versions)) {
+		if err := validatePackageName(name); err != nil {
+			return err
+		}
+		if err := validateVersion(name, versions[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePackageName(name string) error {
+	if !npmPackageName.MatchString(name) {
+		return fmt.Errorf("%q is not an npm package name", name)
+	}
+	return nil
+}
+
+func validateVersion(name, version string) error {
+	if version == "" || strings.ContainsFunc(version, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' }) {
+		return fmt.Errorf("version of %s must be a single word such as 1.2.3, ^1.2 or latest, got %q", name, version)
+	}
+	return nil
+}
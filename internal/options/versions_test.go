@@ -0,0 +1,65 @@
+package options
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.json")
+	data := `{
+  "create-next-app": "15.5.4",
+  "@tanstack/react-query": "latest"
+}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadVersions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"create-next-app": "15.5.4", "@tanstack/react-query": "latest"}
+	if !maps.Equal(got, want) {
+		t.Fatalf("unexpected versions: %v", got)
+	}
+}
+
+func TestDecodeVersionsErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		line int
+		col  int
+	}{
+		{name: "not a mapping", data: "- clsx\n", line: 1, col: 1},
+		{name: "bad package name", data: "clsx: 2.1.1\nClsx: 2.1.1\n", line: 2, col: 1},
+		{name: "duplicate package", data: "clsx: 2.1.1\nclsx: 2.1.0\n", line: 2, col: 1},
+		{name: "empty version", data: "clsx: \"\"\n", line: 1, col: 7},
+		{name: "version list", data: "clsx: [2.1.1]\n", line: 1, col: 7},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeVersions("versions.yaml", []byte(tc.data))
+			var fileErr *FileError
+			if !errors.As(err, &fileErr) {
+				t.Fatalf("expected FileError, got %v", err)
+			}
+			if fileErr.Line != tc.line || fileErr.Column != tc.col {
+				t.Fatalf("expected %d:%d, got %d:%d (%s)", tc.line, tc.col, fileErr.Line, fileErr.Column, fileErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateVersions(t *testing.T) {
+	cfg := Config{ProjectName: "demo", Framework: FrameworkNext, Auth: AuthNone, Database: DatabaseNone}
+	cfg.Versions = map[string]string{"clsx": "2.1.1 --global"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "version of clsx") {
+		t.Fatalf("expected a version error, got %v", err)
+	}
+}
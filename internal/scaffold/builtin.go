@@ -0,0 +1,145 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Builtin step implementations. Builtin steps run in-process, but their
+// Command and Args still spell out the equivalent shell command so dry runs
+// and exported scripts stay truthful.
+const (
+	// builtinMkdir implements `mkdir -p <path>...`.
+	builtinMkdir = "mkdir"
+	// builtinPkgSet implements `npm pkg set <key>=<value>...`, or the
+	// package manager's equivalent from packageManager.pkgSet.
+	builtinPkgSet = "pkg-set"
+	// builtinWriteFile implements `sh -c 'mkdir -p "$(dirname "$1")" && printf "%s" "$2" > "$1"' sh <path> <content>`.
+	builtinWriteFile = "write-file"
+)
+
+// writeFileScript is the shell equivalent of builtinWriteFile.
+const writeFileScript = `mkdir -p "$(dirname "$1")" && printf '%s' "$2" > "$1"`
+
+func runBuiltin(step Step, write outputFunc) error {
+	write(streamStatus, fmt.Sprintf("$ %s\n", step.CommandLine()))
+
+	switch step.Builtin {
+	case builtinMkdir:
+		for _, arg := range step.Args {
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Join(step.Dir, arg), 0o755); err != nil {
+				return err
+			}
+		}
+		return nil
+	case builtinPkgSet:
+		path := filepath.Join(step.Dir, "package.json")
+		fields := pkgSetFields(step.Args)
+		if len(fields) == 0 {
+			return fmt.Errorf("invalid pkg set arguments %q", step.Args)
+		}
+		for _, field := range fields {
+			key, value, _ := strings.Cut(field, "=")
+			if err := setPackageField(path, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case builtinWriteFile:
+		if len(step.Args) != 5 {
+			return fmt.Errorf("invalid write-file arguments %q", step.Args)
+		}
+		path := filepath.Join(step.Dir, step.Args[3])
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(step.Args[4]), 0o644)
+	default:
+		return fmt.Errorf("unknown builtin %q", step.Builtin)
+	}
+}
+
+// pkgSetFields returns the trailing key=value arguments of a pkg set
+// command, whichever package manager renders it.
+func pkgSetFields(args []string) []string {
+	start := len(args)
+	for start > 0 {
+		key, _, ok := strings.Cut(args[start-1], "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t\n\"") {
+			break
+		}
+		start--
+	}
+	return args[start:]
+}
+
+// setPackageField sets a top-level string field in package.json while
+// keeping the order and formatting of the remaining fields.
+func setPackageField(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	type field struct {
+		key   string
+		value json.RawMessage
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("%s: expected a JSON object", path)
+	}
+
+	var fields []field
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fields = append(fields, field{key: tok.(string), value: raw})
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range fields {
+		if fields[i].key == key {
+			fields[i].value = encoded
+			replaced = true
+		}
+	}
+	if !replaced {
+		fields = append([]field{{key: key, value: encoded}}, fields...)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("{\n")
+	for i, f := range fields {
+		k, _ := json.Marshal(f.key)
+		fmt.Fprintf(&out, "  %s: ", k)
+		if err := json.Indent(&out, f.value, "  ", "  "); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if i < len(fields)-1 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("}\n")
+
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
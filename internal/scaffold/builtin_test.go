@@ -0,0 +1,67 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetPackageFieldKeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	original := `{
+  "name": "web",
+  "version": "0.1.0",
+  "private": true,
+  "scripts": {
+    "dev": "next dev --turbopack"
+  }
+}
+`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	step := Step{
+		Command: "npm",
+		Args:    []string{"pkg", "set", "name=@acme/web"},
+		Dir:     dir,
+		Builtin: builtinPkgSet,
+	}
+	if err := runBuiltin(step, func(logStream, string) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "name": "@acme/web",
+  "version": "0.1.0",
+  "private": true,
+  "scripts": {
+    "dev": "next dev --turbopack"
+  }
+}
+`
+	if string(got) != want {
+		t.Fatalf("unexpected package.json:\n%s", got)
+	}
+}
+
+func TestMkdirBuiltin(t *testing.T) {
+	root := t.TempDir()
+	step := Step{
+		Command: "mkdir",
+		Args:    []string{"-p", "apps/nested"},
+		Dir:     root,
+		Builtin: builtinMkdir,
+	}
+	if err := runBuiltin(step, func(logStream, string) {}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(root, "apps", "nested")); err != nil || !info.IsDir() {
+		t.Fatalf("expected directory to exist: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// StateDir is the directory, inside the project, that holds installer state.
+const StateDir = ".ekko"
+
+// ErrNoCheckpoint reports that a directory holds no scaffold to resume.
+var ErrNoCheckpoint = errors.New("no scaffold to resume")
+
+const (
+	checkpointFile    = "state.json"
+	checkpointVersion = 1
+)
+
+// checkpoint is persisted to <project>/.ekko/state.json after every completed
+// step so an interrupted scaffold can be resumed.
+type checkpoint struct {
+	Version   int            `json:"version"`
+	Config    options.Config `json:"config"`
+	Completed []string       `json:"completed"`
+}
+
+// checkpointer records completed steps for a plan. Steps that finish before
+// the project is created are kept in memory and written with the first
+// checkpoint after it.
+type checkpointer struct {
+	mu    sync.Mutex
+	path  string
+	ready string
+	state checkpoint
+}
+
+func newCheckpointer(plan Plan, completed []string) *checkpointer {
+	return &checkpointer{
+		path:  checkpointPath(plan.ProjectPath),
+		ready: projectStep(plan),
+		state: checkpoint{
+			Version:   checkpointVersion,
+			Config:    plan.Config,
+			Completed: slices.Clone(completed),
+		},
+	}
+}
+
+// projectStep returns the ID of the step that creates the project
+// directory. Nothing may be written inside the project before it completes:
+// the scaffolders refuse to run in a directory with unexpected files, which
+// matters when --force reuses an existing one.
+func projectStep(plan Plan) string {
+	for _, step := range plan.Steps {
+		if step.Creates == plan.ProjectPath {
+			return step.ID
+		}
+	}
+	return ""
+}
+
+func checkpointPath(projectPath string) string {
+	return filepath.Join(projectPath, StateDir, checkpointFile)
+}
+
+// complete marks id as done and persists the checkpoint when possible.
+func (c *checkpointer) complete(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !slices.Contains(c.state.Completed, id) {
+		c.state.Completed = append(c.state.Completed, id)
+	}
+
+	if c.ready != "" && !slices.Contains(c.state.Completed, c.ready) {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// clear removes the checkpoint once the run has finished, along with the
+// state directory if nothing else lives in it.
+func (c *checkpointer) clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// Remove fails on a non-empty directory, which is what we want.
+	_ = os.Remove(filepath.Dir(c.path))
+	return nil
+}
+
+// loadCheckpoint reads the checkpoint stored in projectPath.
+func loadCheckpoint(projectPath string) (checkpoint, error) {
+	path := checkpointPath(projectPath)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint{}, fmt.Errorf("%w in %s (missing %s)", ErrNoCheckpoint, projectPath, filepath.Join(StateDir, checkpointFile))
+	}
+	if err != nil {
+		return checkpoint{}, err
+	}
+
+	var state checkpoint
+	if err := json.Unmarshal(data, &state); err != nil {
+		return checkpoint{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if state.Version != checkpointVersion {
+		return checkpoint{}, fmt.Errorf("%s: unsupported checkpoint version %d", path, state.Version)
+	}
+	if err := state.Config.Validate(); err != nil {
+		return checkpoint{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return state, nil
+}
+
+// resumeRoot returns the directory the original run was started from, given
+// the project directory and the configured target directory.
+func resumeRoot(projectPath string, cfg options.Config) string {
+	root := projectPath
+	target := cfg.TargetDir()
+	if target == "." {
+		return root
+	}
+	for dir := target; dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		root = filepath.Dir(root)
+	}
+	return root
+}
@@ -0,0 +1,116 @@
+package scaffold
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+func checkpointConfig() options.Config {
+	return options.Config{
+		ProjectName:    "web",
+		Framework:      options.FrameworkNext,
+		Auth:           options.AuthNone,
+		Database:       options.DatabaseConvex,
+		PackageManager: options.PackageManagerPnpm,
+		Directory:      "apps/web",
+	}
+}
+
+func TestCheckpointPersistsCompletedSteps(t *testing.T) {
+	root := t.TempDir()
+	plan, err := BuildPlan(checkpointConfig(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An existing directory, as with --force, must stay untouched until the
+	// scaffolder has run in it.
+	if err := os.MkdirAll(plan.ProjectPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cp := newCheckpointer(plan, nil)
+	if err := cp.complete(stepDirectory); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(plan.ProjectPath, StateDir)); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint must wait for the framework step, got %v", err)
+	}
+
+	if err := cp.complete(stepFramework); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := loadCheckpoint(plan.ProjectPath)
+	if err != nil {
+		t.Fatalf("loadCheckpoint: %v", err)
+	}
+	if want := []string{stepDirectory, stepFramework}; !slices.Equal(state.Completed, want) {
+		t.Fatalf("completed = %v, want %v", state.Completed, want)
+	}
+	if state.Config.Directory != "apps/web" || state.Config.Database != options.DatabaseConvex {
+		t.Fatalf("config not round-tripped: %+v", state.Config)
+	}
+
+	if err := cp.clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(plan.ProjectPath, StateDir)); !os.IsNotExist(err) {
+		t.Fatalf("expected state dir to be removed, got %v", err)
+	}
+}
+
+func TestLoadCheckpointMissing(t *testing.T) {
+	if _, err := loadCheckpoint(t.TempDir()); !errors.Is(err, ErrNoCheckpoint) {
+		t.Fatalf("expected ErrNoCheckpoint without a checkpoint, got %v", err)
+	}
+}
+
+func TestLoadCheckpointRejectsUnknownVersion(t *testing.T) {
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, StateDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(checkpointPath(project), []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadCheckpoint(project); err == nil {
+		t.Fatal("expected an unsupported version error")
+	}
+}
+
+func TestResumeRoot(t *testing.T) {
+	root := t.TempDir()
+	cfg := checkpointConfig()
+
+	if got := resumeRoot(filepath.Join(root, "apps", "web"), cfg); got != root {
+		t.Fatalf("resumeRoot = %s, want %s", got, root)
+	}
+
+	cfg.Directory = ""
+	if got := resumeRoot(filepath.Join(root, "web"), cfg); got != root {
+		t.Fatalf("resumeRoot = %s, want %s", got, root)
+	}
+}
+
+func TestBuildStepsSkipsCompleted(t *testing.T) {
+	plan, err := BuildPlan(checkpointConfig(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &runner{}
+	steps := r.buildSteps(plan, []string{stepDirectory, stepFramework})
+	if len(steps) != len(plan.Steps)-2 {
+		t.Fatalf("expected %d steps, got %d", len(plan.Steps)-2, len(steps))
+	}
+	if steps[0].title != "Install selected dependencies" {
+		t.Fatalf("expected to resume at dependencies, got %q", steps[0].title)
+	}
+}
@@ -0,0 +1,325 @@
+//go:build e2e
+
+package scaffold
+
+// The end-to-end tests scaffold real projects with the package managers on
+// PATH, without the internet: the package manager is pointed at stubRegistry,
+// which serves the framework scaffolders as local template tarballs and every
+// dependency as an empty package, each at the version versions.json pins.
+// Run them with
+//
+//	go test -tags e2e ./internal/scaffold -run E2E
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+type stubPackage struct {
+	manifest map[string]any
+	tarball  []byte
+}
+
+// stubRegistry is the part of the npm registry API package managers use to
+// install: a package document per name, listing a single version that is
+// also the latest, and that version's tarball.
+type stubRegistry struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu       sync.Mutex
+	packages map[string]stubPackage
+}
+
+func newStubRegistry(t *testing.T) *stubRegistry {
+	r := &stubRegistry{t: t, packages: map[string]stubPackage{}}
+	r.server = httptest.NewServer(r)
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// publish serves a package made of files, under package/ in its tarball,
+// and a package.json built from manifest, at the version the built-in
+// manifest pins.
+func (r *stubRegistry) publish(manifest map[string]any, files map[string][]byte) {
+	r.t.Helper()
+	version := DefaultVersions()[manifest["name"].(string)]
+	if version == "" {
+		r.t.Fatalf("versions.json does not pin %s", manifest["name"])
+	}
+	manifest["version"] = version
+	pkg, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	files = maps.Clone(files)
+	if files == nil {
+		files = map[string][]byte{}
+	}
+	files["package.json"] = pkg
+
+	tarball, err := packTarball(files)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packages[manifest["name"].(string)] = stubPackage{manifest: manifest, tarball: tarball}
+}
+
+// publishEmpty serves each name as a package with nothing in it.
+func (r *stubRegistry) publishEmpty(names ...string) {
+	r.t.Helper()
+	for _, name := range names {
+		r.publish(map[string]any{"name": name}, nil)
+	}
+}
+
+// publishScript serves a package whose only binary is the script at
+// testdata/e2e/<script>, plus extra files.
+func (r *stubRegistry) publishScript(name, bin, script string, extra map[string][]byte) {
+	r.t.Helper()
+	source, err := os.ReadFile(filepath.Join("testdata", "e2e", script))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	files := maps.Clone(extra)
+	if files == nil {
+		files = map[string][]byte{}
+	}
+	files[script] = source
+	r.publish(map[string]any{"name": name, "bin": map[string]string{bin: script}}, files)
+}
+
+// publishScaffolder serves a create-* package that copies the template in
+// testdata/e2e/templates/<template> into the directory it is given.
+func (r *stubRegistry) publishScaffolder(name, bin, template string) {
+	r.t.Helper()
+	dir := filepath.Join("testdata", "e2e", "templates", template)
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		files["template/"+filepath.ToSlash(rel)], err = os.ReadFile(file)
+		return err
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.publishScript(name, bin, "create.js", files)
+}
+
+func (r *stubRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Scoped names arrive as /@scope%2fname, which URL.Path has decoded.
+	name, _, isTarball := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/-/")
+	r.mu.Lock()
+	pkg, ok := r.packages[name]
+	r.mu.Unlock()
+	if !ok {
+		r.t.Logf("registry: nothing published at %s", req.URL.Path)
+		http.NotFound(w, req)
+		return
+	}
+
+	if isTarball {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(pkg.tarball)
+		return
+	}
+
+	sha1Sum := sha1.Sum(pkg.tarball)
+	sha512Sum := sha512.Sum512(pkg.tarball)
+	number := pkg.manifest["version"].(string)
+	version := maps.Clone(pkg.manifest)
+	version["dist"] = map[string]string{
+		"tarball":   r.server.URL + "/" + name + "/-/" + path.Base(name) + "-" + number + ".tgz",
+		"shasum":    hex.EncodeToString(sha1Sum[:]),
+		"integrity": "sha512-" + base64.StdEncoding.EncodeToString(sha512Sum[:]),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"name":      name,
+		"dist-tags": map[string]string{"latest": number},
+		"versions":  map[string]any{number: version},
+	})
+}
+
+// packTarball builds an npm package tarball holding files under package/.
+func packTarball(files map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		mode := int64(0o644)
+		if strings.HasSuffix(name, ".js") {
+			mode = 0o755
+		}
+		err := tw.WriteHeader(&tar.Header{Name: "package/" + name, Mode: mode, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// useRegistry points npm and pnpm at url, with caches and a user config of
+// their own so nothing from the machine's setup leaks in.
+func useRegistry(t *testing.T, url string) {
+	home := t.TempDir()
+	userConfig := filepath.Join(home, ".npmrc")
+	if err := os.WriteFile(userConfig, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{
+		"registry":        url + "/",
+		"userconfig":      userConfig,
+		"cache":           filepath.Join(home, "npm-cache"),
+		"cache_dir":       filepath.Join(home, "pnpm-cache"),
+		"store_dir":       filepath.Join(home, "pnpm-store"),
+		"audit":           "false",
+		"fund":            "false",
+		"update_notifier": "false",
+	} {
+		t.Setenv("npm_config_"+key, value)
+	}
+}
+
+// offlineExecutor runs commands for real, except that it never opens the
+// finished project in an editor.
+type offlineExecutor struct {
+	systemExecutor
+}
+
+func (e offlineExecutor) run(ctx context.Context, write outputFunc, dir string, name string, args ...string) error {
+	if name == "code" {
+		return exec.ErrNotFound
+	}
+	return e.systemExecutor.run(ctx, write, dir, name, args...)
+}
+
+func TestE2EOfflineScaffold(t *testing.T) {
+	configs := []options.Config{
+		{
+			ProjectName: "web",
+			Framework:   options.FrameworkNext,
+			Auth:        options.AuthClerk,
+			Database:    options.DatabaseDrizzle,
+			Tooling:     options.ToolingOptions,
+			ShadcnColor: "slate",
+		},
+		{
+			ProjectName: "@acme/start",
+			Directory:   "apps/start",
+			Framework:   options.FrameworkTanstackStart,
+			Auth:        options.AuthBetterAuth,
+			Database:    options.DatabaseConvex,
+			Tooling:     []options.ToolingOption{options.ToolShadcn, options.ToolResend},
+		},
+	}
+
+	registry := newStubRegistry(t)
+	registry.publishScaffolder("create-next-app", "create-next-app", "next")
+	registry.publishScaffolder("@tanstack/create-start", "create-start", "tanstack-start")
+	registry.publishScript("shadcn", "shadcn", "shadcn.js", nil)
+	for _, cfg := range configs {
+		registry.publishEmpty(collectDependencies(cfg)...)
+	}
+	useRegistry(t, registry.server.URL)
+
+	for _, pm := range []options.PackageManager{options.PackageManagerPnpm, options.PackageManagerNpm} {
+		t.Run(string(pm), func(t *testing.T) {
+			if _, err := exec.LookPath(string(pm)); err != nil {
+				t.Skipf("%s is not installed", pm)
+			}
+			for _, cfg := range configs {
+				cfg.PackageManager = pm
+				t.Run(configName(cfg), func(t *testing.T) {
+					root := t.TempDir()
+					plan, err := BuildPlan(cfg, root)
+					if err != nil {
+						t.Fatal(err)
+					}
+					r := newRunner(t.Context(), log.New(io.Discard), root)
+					r.executor = offlineExecutor{}
+
+					opts := headless(t, io.Discard)
+					if err := r.execute(t.Context(), plan, nil, opts); err != nil {
+						installLog, _ := os.ReadFile(opts.LogFile)
+						t.Fatalf("scaffold failed: %v\n%s", err, installLog)
+					}
+
+					data, err := os.ReadFile(filepath.Join(plan.ProjectPath, "package.json"))
+					if err != nil {
+						t.Fatal(err)
+					}
+					var pkg struct {
+						Name            string            `json:"name"`
+						Dependencies    map[string]string `json:"dependencies"`
+						DevDependencies map[string]string `json:"devDependencies"`
+					}
+					if err := json.Unmarshal(data, &pkg); err != nil {
+						t.Fatal(err)
+					}
+					if pkg.Name != cfg.ProjectName {
+						t.Errorf("package name = %q, want %q", pkg.Name, cfg.ProjectName)
+					}
+					got := slices.Sorted(maps.Keys(pkg.Dependencies))
+					want := slices.Sorted(slices.Values(collectDependencies(cfg)))
+					if !slices.Equal(got, want) || len(pkg.DevDependencies) > 0 {
+						t.Fatalf("package.json dependencies = %v, devDependencies = %v, want exactly %v", got, slices.Sorted(maps.Keys(pkg.DevDependencies)), want)
+					}
+
+					for dep, version := range pkg.Dependencies {
+						if pinned := DefaultVersions()[dep]; version != pinned {
+							t.Errorf("%s was installed as %s, want the pinned %s", dep, version, pinned)
+						}
+					}
+
+					for _, file := range []string{versionsFile, "components.json", "src/components/ui/button.tsx"} {
+						if _, err := os.Stat(filepath.Join(plan.ProjectPath, file)); err != nil {
+							t.Errorf("expected the scaffold to write %s: %v", file, err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package scaffold
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// Event types written by --output=jsonl, one JSON object per line.
+const (
+	eventRunStart   = "run-start"
+	eventStepStart  = "step-start"
+	eventLog        = "log"
+	eventStepFinish = "step-finish"
+	eventRunFinish  = "run-finish"
+)
+
+// event is a single line of the JSON event stream. Fields that do not apply
+// to an event type are omitted.
+type event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+
+	// run-start
+	Project   string      `json:"project,omitempty"`
+	Steps     []eventStep `json:"steps,omitempty"`
+	Completed []string    `json:"completed,omitempty"`
+
+	// step-start, log and step-finish
+	Step  string `json:"step,omitempty"`
+	Title string `json:"title,omitempty"`
+
+	// log
+	Stream logStream `json:"stream,omitempty"`
+	Line   string    `json:"line,omitempty"`
+
+	// step-finish and run-finish
+	Status      string `json:"status,omitempty"`
+	DurationMs  *int64 `json:"durationMs,omitempty"`
+	ExitCode    *int   `json:"exitCode,omitempty"`
+	SoftFailure bool   `json:"softFailure,omitempty"`
+	Error       string `json:"error,omitempty"`
+
+	// run-finish
+	Config *options.Config `json:"config,omitempty"`
+}
+
+type eventStep struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Needs    []string `json:"needs,omitempty"`
+	Optional bool     `json:"optional,omitempty"`
+}
+
+// jsonReporter writes the install as a JSON Lines event stream.
+type jsonReporter struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	steps []installStep
+	begun time.Time
+	start []time.Time
+}
+
+func newJSONReporter(w io.Writer, steps []installStep) *jsonReporter {
+	return &jsonReporter{
+		enc:   json.NewEncoder(w),
+		steps: steps,
+		start: make([]time.Time, len(steps)),
+	}
+}
+
+func (r *jsonReporter) emit(ev event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ev.Time = time.Now().UTC()
+	// The stream is best effort; a closed reader must not fail the scaffold.
+	_ = r.enc.Encode(ev)
+}
+
+func (r *jsonReporter) runStarted(plan Plan, completed []string) {
+	r.begun = time.Now()
+	steps := make([]eventStep, len(r.steps))
+	for i, step := range r.steps {
+		steps[i] = eventStep{ID: step.id, Title: step.title, Needs: step.needs, Optional: step.optional}
+	}
+	r.emit(event{
+		Type:      eventRunStart,
+		Project:   plan.ProjectPath,
+		Steps:     steps,
+		Completed: completed,
+	})
+}
+
+func (r *jsonReporter) runFinished(cfg options.Config, err error) {
+	ev := event{
+		Type:       eventRunFinish,
+		Status:     "succeeded",
+		DurationMs: durationMs(time.Since(r.begun)),
+		Config:     &cfg,
+	}
+	if err != nil {
+		ev.Status = "failed"
+		if errors.Is(err, ErrInstallCancelled) || errors.Is(err, context.Canceled) {
+			ev.Status = "cancelled"
+		}
+		ev.Error = err.Error()
+	}
+	r.emit(ev)
+}
+
+func (r *jsonReporter) stepStarted(i int) {
+	r.start[i] = time.Now()
+	r.emit(event{Type: eventStepStart, Step: r.steps[i].id, Title: r.steps[i].title})
+}
+
+func (r *jsonReporter) stepOutput(i int, stream logStream, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		r.emit(event{Type: eventLog, Step: r.steps[i].id, Stream: stream, Line: line})
+	}
+}
+
+func (r *jsonReporter) stepFinished(i int, status stepStatus, err error) {
+	ev := event{
+		Type:       eventStepFinish,
+		Step:       r.steps[i].id,
+		Title:      r.steps[i].title,
+		DurationMs: durationMs(time.Since(r.start[i])),
+		ExitCode:   exitCode(err),
+	}
+	switch status {
+	case stepSucceeded:
+		ev.Status = "succeeded"
+	case stepSkipped:
+		ev.Status = "skipped"
+		ev.SoftFailure = err != nil
+		if err == nil {
+			// Skipped because a need did not complete; it never ran.
+			ev.ExitCode = nil
+		}
+	default:
+		ev.Status = "failed"
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	r.emit(ev)
+}
+
+func durationMs(d time.Duration) *int64 {
+	ms := d.Milliseconds()
+	return &ms
+}
+
+// exitCode returns the exit status of the process behind err, 0 for nil, or
+// nil when err did not come from an exited process. Errors report a status
+// through an ExitCode method, as *exec.ExitError does.
+func exitCode(err error) *int {
+	code := 0
+	if err != nil {
+		var exited interface{ ExitCode() int }
+		if !errors.As(err, &exited) {
+			return nil
+		}
+		code = exited.ExitCode()
+	}
+	return &code
+}
@@ -0,0 +1,100 @@
+package scaffold
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+func decodeEvents(t *testing.T, data []byte) []event {
+	t.Helper()
+	var events []event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var ev event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid event line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestJSONReporterEventStream(t *testing.T) {
+	steps := plainSteps(errors.New("registry unreachable"))
+	graph, err := newInstallGraph(steps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	report := newJSONReporter(&out, steps)
+	cfg := options.Config{ProjectName: "demo", Framework: options.FrameworkNext}
+	report.runStarted(Plan{ProjectPath: "/work/demo", Config: cfg}, nil)
+	runErr := runHeadless(context.Background(), graph, report, nil)
+	report.runFinished(cfg, runErr)
+
+	events := decodeEvents(t, out.Bytes())
+
+	var types []string
+	for _, ev := range events {
+		types = append(types, fmt.Sprintf("%s:%s", ev.Type, ev.Step))
+	}
+	want := []string{
+		"run-start:",
+		"step-start:framework",
+		"log:framework",
+		"step-finish:framework",
+		"step-start:shadcn-init",
+		"log:shadcn-init",
+		"log:shadcn-init",
+		"step-finish:shadcn-init",
+		"step-start:shadcn-add",
+		"log:shadcn-add",
+		"step-finish:shadcn-add",
+		"run-finish:",
+	}
+	if fmt.Sprint(types) != fmt.Sprint(want) {
+		t.Fatalf("unexpected events:\nwant %v\n got %v", want, types)
+	}
+
+	if len(events[0].Steps) != 3 || events[0].Project != "/work/demo" {
+		t.Fatalf("unexpected run-start: %+v", events[0])
+	}
+	if ev := events[2]; ev.Stream != streamStatus || ev.Line != "$ pnpm dlx create-next-app@latest demo" {
+		t.Fatalf("unexpected log event: %+v", ev)
+	}
+	if ev := events[5]; ev.Stream != streamStderr || ev.Line != "fetching registry" {
+		t.Fatalf("unexpected log event: %+v", ev)
+	}
+	if ev := events[3]; ev.Status != "succeeded" || ev.ExitCode == nil || *ev.ExitCode != 0 || ev.DurationMs == nil {
+		t.Fatalf("unexpected step-finish: %+v", ev)
+	}
+	if ev := events[7]; ev.Status != "skipped" || !ev.SoftFailure || ev.Error != "registry unreachable" {
+		t.Fatalf("unexpected soft failure: %+v", ev)
+	}
+	if ev := events[10]; ev.Status != "skipped" || ev.SoftFailure || ev.ExitCode != nil {
+		t.Fatalf("unexpected skipped step: %+v", ev)
+	}
+	last := events[len(events)-1]
+	if last.Status != "succeeded" || last.Config == nil || last.Config.ProjectName != "demo" {
+		t.Fatalf("unexpected run-finish: %+v", last)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	if code := exitCode(fmt.Errorf("run sh: %w", err)); code == nil || *code != 3 {
+		t.Fatalf("expected exit code 3, got %v", code)
+	}
+	if code := exitCode(errors.New("not a process")); code != nil {
+		t.Fatalf("expected no exit code, got %d", *code)
+	}
+}
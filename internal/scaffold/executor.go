@@ -0,0 +1,99 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// executor runs the external commands a plan is made of. write receives the
+// command's output; dir is its working directory, or the current one when
+// empty. A failed command's error says how it exited (see exitCode).
+type executor interface {
+	run(ctx context.Context, write outputFunc, dir string, name string, args ...string) error
+}
+
+// systemExecutor runs commands as child processes. Under the install view
+// (see terminalFrom) interactive commands get a pseudo-terminal.
+type systemExecutor struct{}
+
+func (systemExecutor) run(ctx context.Context, write outputFunc, dir string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if dir != "" {
+		cmd.Dir = dir
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	var stdout io.Reader
+	var ptmx, tty *os.File
+	term := terminalFrom(ctx)
+	if term != nil && term.interactive {
+		ptmx, tty, err = pty.Open()
+		if err != nil {
+			write(streamStatus, fmt.Sprintf("⚠️ No pseudo-terminal (%v); the step cannot prompt for input.\n", err))
+		} else {
+			defer ptmx.Close()
+			_ = pty.Setsize(ptmx, &pty.Winsize{Rows: terminalRows, Cols: uint16(max(term.cols, 40))})
+			cmd.Stdin, cmd.Stdout = tty, tty
+			attachTerminal(cmd)
+			stdout = ptmx
+		}
+	}
+	if stdout == nil {
+		pipe, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		stdout = pipe
+		// The command runs in a process group of its own, which is in the
+		// background on a terminal: reading one would stop it with SIGTTIN.
+		// Leaving Stdin nil gives it /dev/null instead.
+		killProcessGroup(cmd)
+	}
+
+	err = cmd.Start()
+	if tty != nil {
+		// The child has its own copy of the terminal; closing ours lets
+		// reads from ptmx end once the child exits.
+		tty.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("start %s: %w", name, err)
+	}
+	if ptmx != nil {
+		term.attach(ptmx)
+	}
+
+	var wg sync.WaitGroup
+	copyStream := func(r io.Reader, stream logStream, partial bool) {
+		defer wg.Done()
+		// Reading a pseudo-terminal fails with EIO once the child has
+		// exited; that is its end of file.
+		if err := copyOutput(r, stream, partial, write); err != nil && !errors.Is(err, syscall.EIO) {
+			write(streamStatus, err.Error()+"\n")
+		}
+	}
+
+	wg.Add(2)
+	// Only prompts need to show before their line ends.
+	go copyStream(stdout, streamStdout, ptmx != nil)
+	go copyStream(stderr, streamStderr, false)
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("run %s %s: %w", name, strings.Join(args, " "), err)
+	}
+
+	return nil
+}
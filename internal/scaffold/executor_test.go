@@ -0,0 +1,155 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCommand is a command the fake executor expects to run and what it
+// does when it does.
+type fakeCommand struct {
+	dir  string
+	name string
+	args []string
+
+	// stdout and stderr are written a line at a time.
+	stdout []string
+	stderr []string
+	// effect stands in for what the command does to the filesystem.
+	effect func() error
+	// exitCode fails the command with that exit status.
+	exitCode int
+	// block keeps the command running until its context ends; started is
+	// closed once it does.
+	block   bool
+	started chan struct{}
+}
+
+func (c fakeCommand) String() string {
+	return fmt.Sprintf("%s (in %s)", commandLine(c.name, c.args), c.dir)
+}
+
+// fakeExitError is how a fake command reports a non-zero exit status.
+type fakeExitError int
+
+func (e fakeExitError) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e fakeExitError) ExitCode() int { return int(e) }
+
+// fakeExecutor stands in for systemExecutor. Every command it is asked to
+// run must match an expected command that has not run yet, by name, args
+// and dir; steps that run concurrently may do so in any order. verify fails
+// the test for expected commands that never ran.
+type fakeExecutor struct {
+	errorf func(format string, args ...any)
+
+	mu       sync.Mutex
+	expected []*fakeCommand
+	ran      []bool
+	// calls lists the commands that ran, in order.
+	calls []string
+}
+
+func newFakeExecutor(t *testing.T) *fakeExecutor {
+	f := &fakeExecutor{errorf: t.Errorf}
+	t.Cleanup(f.verify)
+	return f
+}
+
+func (f *fakeExecutor) expect(cmd fakeCommand) *fakeCommand {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expected = append(f.expected, &cmd)
+	f.ran = append(f.ran, false)
+	return &cmd
+}
+
+func (f *fakeExecutor) run(ctx context.Context, write outputFunc, dir string, name string, args ...string) error {
+	cmd := f.claim(dir, name, args)
+	if cmd == nil {
+		f.errorf("unexpected command: %s", fakeCommand{dir: dir, name: name, args: args})
+		return errors.New("unexpected command")
+	}
+
+	for _, line := range cmd.stdout {
+		write(streamStdout, line+"\n")
+	}
+	for _, line := range cmd.stderr {
+		write(streamStderr, line+"\n")
+	}
+	if cmd.effect != nil {
+		if err := cmd.effect(); err != nil {
+			return err
+		}
+	}
+	if cmd.block {
+		close(cmd.started)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if cmd.exitCode != 0 {
+		return fmt.Errorf("run %s: %w", commandLine(name, args), fakeExitError(cmd.exitCode))
+	}
+	return nil
+}
+
+func (f *fakeExecutor) claim(dir, name string, args []string) *fakeCommand {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, cmd := range f.expected {
+		if !f.ran[i] && cmd.dir == dir && cmd.name == name && slices.Equal(cmd.args, args) {
+			f.ran[i] = true
+			f.calls = append(f.calls, commandLine(name, args))
+			return cmd
+		}
+	}
+	return nil
+}
+
+func (f *fakeExecutor) verify() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, cmd := range f.expected {
+		if !f.ran[i] {
+			f.errorf("expected command did not run: %s", cmd)
+		}
+	}
+}
+
+func TestFakeExecutorRejectsUnexpectedCommands(t *testing.T) {
+	var failures []string
+	f := &fakeExecutor{errorf: func(format string, args ...any) {
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}}
+	f.expect(fakeCommand{dir: "/app", name: "pnpm", args: []string{"add", "clsx"}})
+
+	var out strings.Builder
+	write := func(_ logStream, text string) { out.WriteString(text) }
+	if err := f.run(t.Context(), write, "/elsewhere", "pnpm", "add", "clsx"); err == nil {
+		t.Fatal("a command in the wrong directory must not match")
+	}
+	if err := f.run(t.Context(), write, "/app", "pnpm", "add", "clsx"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.run(t.Context(), write, "/app", "pnpm", "add", "clsx"); err == nil {
+		t.Fatal("an expected command must only match once")
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected both unexpected commands to be reported, got %q", failures)
+	}
+}
+
+func TestSystemExecutorReportsExitCode(t *testing.T) {
+	var out outputRecorder
+	err := systemExecutor{}.run(t.Context(), out.write, t.TempDir(), "sh", "-c", "echo partial >&2; exit 3")
+	if code := exitCode(err); code == nil || *code != 3 {
+		t.Fatalf("exitCode(%v) = %v, want 3", err, code)
+	}
+	if !strings.Contains(out.joined(), "partial") {
+		t.Fatalf("expected stderr to be passed on, got %q", out.joined())
+	}
+}
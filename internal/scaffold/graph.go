@@ -0,0 +1,190 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+)
+
+// stepStatus tracks an installStep through the install graph.
+type stepStatus int
+
+const (
+	stepPending stepStatus = iota
+	stepRunning
+	// stepAwaiting is a failed step waiting for a retry, skip or abort
+	// decision.
+	stepAwaiting
+	stepSucceeded
+	// stepSkipped covers optional steps that failed or whose needs did not
+	// complete.
+	stepSkipped
+	stepFailed
+)
+
+// finished reports whether the step has reached a final status.
+func (s stepStatus) finished() bool {
+	return s >= stepSucceeded
+}
+
+// installGraph schedules installSteps by their needs and decides what a
+// failure means (see fail). It only tracks state; the install view and the
+// headless runner start the steps it reports as ready and record how they
+// ended.
+type installGraph struct {
+	steps  []installStep
+	status []stepStatus
+	index  map[string]int
+}
+
+// newInstallGraph checks that every need refers to an earlier step, which
+// also rules out cycles, or to one of the completed steps of an earlier run,
+// which count as succeeded.
+func newInstallGraph(steps []installStep, completed []string) (*installGraph, error) {
+	g := &installGraph{
+		steps:  steps,
+		status: make([]stepStatus, len(steps)),
+		index:  make(map[string]int, len(steps)),
+	}
+	for i, step := range steps {
+		for _, need := range step.needs {
+			if _, earlier := g.index[need]; earlier || slices.Contains(completed, need) {
+				continue
+			}
+			if g.declaredFrom(need, i) {
+				return nil, fmt.Errorf("step %q needs %q, which does not come before it", step.id, need)
+			}
+			return nil, fmt.Errorf("step %q needs unknown step %q", step.id, need)
+		}
+		g.index[step.id] = i
+	}
+	return g, nil
+}
+
+// declaredFrom reports whether id names step i or a later one.
+func (g *installGraph) declaredFrom(id string, i int) bool {
+	for _, step := range g.steps[i:] {
+		if step.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ready returns the pending steps whose needs have all finished, in plan
+// order.
+func (g *installGraph) ready() []int {
+	var ready []int
+	for i, step := range g.steps {
+		if g.status[i] != stepPending {
+			continue
+		}
+		ok := true
+		for _, need := range step.needs {
+			if j, inGraph := g.index[need]; inGraph && !g.status[j].finished() {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			ready = append(ready, i)
+		}
+	}
+	return ready
+}
+
+// schedule marks every pending step whose needs have finished as running
+// and passes it to start. Optional steps whose needs did not all succeed are
+// marked skipped and passed to skip instead, which may unblock others, so it
+// repeats until nothing new becomes ready. A required step with an unmet need
+// is marked failed and reported as an error.
+func (g *installGraph) schedule(start func(i int), skip func(i int, need string)) error {
+	for {
+		ready := g.ready()
+		if len(ready) == 0 {
+			return nil
+		}
+		for _, i := range ready {
+			need, blocked := g.unmet(i)
+			switch {
+			case !blocked:
+				g.status[i] = stepRunning
+				start(i)
+			case g.steps[i].optional:
+				g.status[i] = stepSkipped
+				skip(i, need)
+			default:
+				g.status[i] = stepFailed
+				return fmt.Errorf("%s did not complete; cannot continue", need)
+			}
+		}
+	}
+}
+
+// failureAction is what an install does after a step fails.
+type failureAction int
+
+const (
+	// failurePause waits for the user to retry, skip or abort.
+	failurePause failureAction = iota
+	// failureSkip skips the optional step and carries on.
+	failureSkip
+	// failureAbort stops the run.
+	failureAbort
+)
+
+// fail records that step i failed and decides what happens next, for the
+// install view and the headless front ends alike: a cancelled run aborts, an
+// interactive one pauses for a decision, and otherwise optional steps are
+// skipped and the rest abort. The step's status is updated to match.
+func (g *installGraph) fail(i int, interactive, cancelled bool) failureAction {
+	switch {
+	case cancelled:
+		g.status[i] = stepFailed
+		return failureAbort
+	case interactive:
+		g.status[i] = stepAwaiting
+		return failurePause
+	case g.steps[i].optional:
+		g.status[i] = stepSkipped
+		return failureSkip
+	default:
+		g.status[i] = stepFailed
+		return failureAbort
+	}
+}
+
+// skipNotice is logged for a step skipped because need did not complete.
+func skipNotice(need string) string {
+	return fmt.Sprintf("ℹ️ Skipping because %q did not complete.\n", need)
+}
+
+// unmet returns the title of the first need of step i that did not succeed.
+func (g *installGraph) unmet(i int) (string, bool) {
+	for _, need := range g.steps[i].needs {
+		if j, ok := g.index[need]; ok && g.status[j] != stepSucceeded {
+			return g.steps[j].title, true
+		}
+	}
+	return "", false
+}
+
+// count returns how many steps have the given status.
+func (g *installGraph) count(status stepStatus) int {
+	n := 0
+	for _, s := range g.status {
+		if s == status {
+			n++
+		}
+	}
+	return n
+}
+
+// done reports whether every step has finished.
+func (g *installGraph) done() bool {
+	for _, s := range g.status {
+		if !s.finished() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestFailurePolicyMatchesAcrossFrontEnds runs the same failures through the
+// install view, without prompts, and through the headless runner behind
+// --plain and --output=jsonl; both must leave the steps in the same state.
+func TestFailurePolicyMatchesAcrossFrontEnds(t *testing.T) {
+	failure := errors.New("exit status 1")
+	step := func(id string, optional bool, err error, needs ...string) installStep {
+		return installStep{
+			id:       id,
+			title:    id,
+			needs:    needs,
+			optional: optional,
+			run:      func(context.Context, outputFunc) error { return err },
+		}
+	}
+
+	tests := []struct {
+		name   string
+		steps  []installStep
+		want   []stepStatus
+		failed bool
+	}{
+		{
+			name:  "all succeed",
+			steps: []installStep{step("framework", false, nil), step("deps", false, nil, "framework")},
+			want:  []stepStatus{stepSucceeded, stepSucceeded},
+		},
+		{
+			name: "optional failure skips dependents",
+			steps: []installStep{
+				step("framework", false, nil),
+				step("shadcn-init", true, failure, "framework"),
+				step("shadcn-add", true, nil, "shadcn-init"),
+				step("versions", false, nil, "framework"),
+			},
+			want: []stepStatus{stepSucceeded, stepSkipped, stepSkipped, stepSucceeded},
+		},
+		{
+			name:   "required failure stops dependents",
+			steps:  []installStep{step("framework", false, failure), step("deps", false, nil, "framework")},
+			want:   []stepStatus{stepFailed, stepPending},
+			failed: true,
+		},
+		{
+			name:   "required step after a skipped one",
+			steps:  []installStep{step("shadcn-init", true, failure), step("deps", false, nil, "shadcn-init")},
+			want:   []stepStatus{stepSkipped, stepFailed},
+			failed: true,
+		},
+	}
+
+	frontEnds := map[string]func(t *testing.T, steps []installStep) ([]stepStatus, error){
+		"view": func(t *testing.T, steps []installStep) ([]stepStatus, error) {
+			m := startInstallModel(t, steps, false)
+			for m.err == nil && !m.graph.done() {
+				select {
+				case msg := <-m.events:
+					m.Update(msg)
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for steps")
+				}
+			}
+			return m.graph.status, m.err
+		},
+		"headless": func(t *testing.T, steps []installStep) ([]stepStatus, error) {
+			graph, err := newInstallGraph(steps, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = runHeadless(context.Background(), graph, newPlainReporter(io.Discard, steps, false), nil)
+			return graph.status, err
+		},
+	}
+
+	for _, tt := range tests {
+		for name, run := range frontEnds {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				status, err := run(t, slices.Clone(tt.steps))
+				if !slices.Equal(status, tt.want) {
+					t.Fatalf("statuses = %v, want %v", status, tt.want)
+				}
+				if (err != nil) != tt.failed {
+					t.Fatalf("err = %v, want failure=%v", err, tt.failed)
+				}
+			})
+		}
+	}
+}
+
+func TestInstallGraphFail(t *testing.T) {
+	tests := []struct {
+		name        string
+		optional    bool
+		interactive bool
+		cancelled   bool
+		want        failureAction
+		status      stepStatus
+	}{
+		{name: "required", want: failureAbort, status: stepFailed},
+		{name: "optional", optional: true, want: failureSkip, status: stepSkipped},
+		{name: "interactive", interactive: true, want: failurePause, status: stepAwaiting},
+		{name: "cancelled", optional: true, interactive: true, cancelled: true, want: failureAbort, status: stepFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := newInstallGraph([]installStep{{id: "step", optional: tt.optional}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := graph.fail(0, tt.interactive, tt.cancelled); got != tt.want || graph.status[0] != tt.status {
+				t.Fatalf("fail = %v with status %v, want %v with %v", got, graph.status[0], tt.want, tt.status)
+			}
+		})
+	}
+}
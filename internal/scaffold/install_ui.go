@@ -2,24 +2,45 @@ package scaffold
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/muesli/reflow/wordwrap"
 )
 
-func runInstallUI(ctx context.Context, steps []installStep) error {
-	model := newInstallModel(ctx, steps)
+// ErrInstallCancelled reports that the user pressed ctrl+c during install.
+var ErrInstallCancelled = errors.New("installation cancelled by user")
+
+// runInstallUI runs the graph's steps as their needs allow, several at a
+// time when they are independent. When interactive is set a failed step
+// pauses and asks whether to retry, skip or abort; otherwise optional steps
+// are skipped and any other failure aborts.
+func runInstallUI(ctx context.Context, graph *installGraph, interactive bool) error {
+	model := newInstallModel(ctx, graph)
+	model.interactive = interactive
 	program := tea.NewProgram(model, tea.WithContext(ctx))
 
 	final, err := program.Run()
+	// Make sure no step is still writing to disk before the caller inspects
+	// or rolls back the project directory.
+	model.stop()
 	if err != nil {
 		return err
 	}
+	if werr := writeTimingSummary(os.Stderr, graph, model.times, model.now()); werr != nil {
+		return werr
+	}
 
 	if m, ok := final.(*installModel); ok {
 		return m.err
@@ -29,26 +50,51 @@ func runInstallUI(ctx context.Context, steps []installStep) error {
 }
 
 type installModel struct {
-	ctx     context.Context
-	steps   []installStep
-	current int
+	ctx    context.Context
+	cancel context.CancelFunc
+	graph  *installGraph
 
 	progress progress.Model
 	viewport viewport.Model
-
-	logs strings.Builder
-	err  error
+	spinner  spinner.Model
+
+	times *stepTimes
+	now   func() time.Time
+
+	logs *logView
+	// search is the query being typed after "/"; searching is set while
+	// it has focus.
+	search    textinput.Model
+	searching bool
+	// match is the search match n and N last moved to.
+	match int
+	// notice reports a problem with the last log viewer action.
+	notice string
+	// attempt is where the latest attempt of each step starts in its log,
+	// so the failure panel only shows the output of that attempt.
+	attempt []int
+	err     error
+
+	interactive bool
+	// failures queues steps paused on a failure, oldest first.
+	failures []int
+	stepErrs []error
+
+	// inputs holds the terminal input of running interactive steps; keys
+	// typed while one is running go to it.
+	inputs []io.Writer
 
 	width int
 
-	chunks chan string
-	done   chan error
+	events  chan tea.Msg
+	running sync.WaitGroup
 
 	percent      float64
-	stepProgress float64
+	stepProgress []float64
+	estimators   []*progressEstimator
 }
 
-func newInstallModel(ctx context.Context, steps []installStep) *installModel {
+func newInstallModel(ctx context.Context, graph *installGraph) *installModel {
 	pr := progress.New(
 		progress.WithGradient("#7c3aed", "#f472b6"),
 	)
@@ -59,27 +105,69 @@ func newInstallModel(ctx context.Context, steps []installStep) *installModel {
 		BorderForeground(lipgloss.Color("#9d4edd")).
 		Padding(1, 2)
 
+	sp := spinner.New(spinner.WithSpinner(spinner.Dot))
+	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#f472b6"))
+
+	search := textinput.New()
+	search.Prompt = "/"
+
+	ctx, cancel := context.WithCancel(ctx)
+	n := len(graph.steps)
+
 	return &installModel{
-		ctx:      ctx,
-		steps:    steps,
-		progress: pr,
-		viewport: vp,
+		ctx:          ctx,
+		cancel:       cancel,
+		graph:        graph,
+		progress:     pr,
+		viewport:     vp,
+		spinner:      sp,
+		times:        newStepTimes(n),
+		now:          time.Now,
+		logs:         newLogView(n),
+		search:       search,
+		attempt:      make([]int, n),
+		stepErrs:     make([]error, n),
+		inputs:       make([]io.Writer, n),
+		events:       make(chan tea.Msg),
+		stepProgress: make([]float64, n),
+		estimators:   make([]*progressEstimator, n),
 	}
 }
 
 type stepChunkMsg struct {
-	text string
+	index  int
+	stream logStream
+	text   string
+}
+
+// stepInputMsg is delivered when an interactive step's process is ready for
+// input.
+type stepInputMsg struct {
+	index int
+	input io.Writer
 }
 
 type stepFinishedMsg struct {
+	index int
+	err   error
+}
+
+// pagerClosedMsg is delivered when the pager opened on the log exits.
+type pagerClosedMsg struct {
+	err error
+}
+
+// installCancelledMsg is delivered when the run context ends while steps
+// may still be running.
+type installCancelledMsg struct {
 	err error
 }
 
 func (m *installModel) Init() tea.Cmd {
-	if len(m.steps) == 0 {
-		return nil
+	if len(m.graph.steps) == 0 {
+		return tea.Quit
 	}
-	return m.startCurrentStep()
+	return tea.Batch(m.schedule(), m.waitForActivity(), m.spinner.Tick)
 }
 
 func (m *installModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -95,33 +183,65 @@ func (m *installModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.Width = vpWidth
 		m.viewport.Height = vpHeight
 		m.progress.Width = vpWidth
-		m.viewport.SetContent(m.logs.String())
-		m.viewport.GotoBottom()
+		m.updateViewport()
 		return m, nil
-	case stepChunkMsg:
-		m.appendChunk(msg.text)
-		var cmds []tea.Cmd
-		if cmd := m.bumpStepProgress(); cmd != nil {
-			cmds = append(cmds, cmd)
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.err = ErrInstallCancelled
+			m.cancel()
+			return m, tea.Quit
+		}
+		if len(m.failures) > 0 {
+			return m, m.handleFailureKey(msg.String())
+		}
+		if i := m.promptStep(); i >= 0 {
+			if input := keyInput(msg); input != nil {
+				// The step may have exited already; its result follows.
+				_, _ = m.inputs[i].Write(input)
+			}
+			return m, nil
+		}
+		if m.searching {
+			return m, m.handleSearchKey(msg)
+		}
+		if cmd, ok := m.handleLogKey(msg.String()); ok {
+			return m, cmd
 		}
-		cmds = append(cmds, m.waitForActivity())
-		return m, tea.Batch(cmds...)
+	case pagerClosedMsg:
+		m.notice = ""
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("pager: %v", msg.err)
+		}
+		return m, nil
+	case stepInputMsg:
+		m.inputs[msg.index] = msg.input
+		return m, m.waitForActivity()
+	case stepChunkMsg:
+		m.appendChunk(msg.index, msg.stream, msg.text)
+		return m, tea.Batch(m.observeProgress(msg.index, msg.text), m.waitForActivity())
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
 	case stepFinishedMsg:
-		m.stepProgress = 1
-		m.percent = m.currentPercent()
-		progressCmd := m.progress.SetPercent(m.percent)
-
+		m.stepProgress[msg.index] = 1
+		m.inputs[msg.index] = nil
+		m.times.finish(msg.index, m.now())
+		var cmd tea.Cmd
 		if msg.err != nil {
-			m.err = msg.err
-			return m, tea.Batch(progressCmd, tea.Quit)
+			cmd = m.fail(msg.index, msg.err)
+		} else {
+			m.graph.status[msg.index] = stepSucceeded
+			cmd = m.schedule()
 		}
-
-		m.current++
-		if m.current >= len(m.steps) {
-			return m, tea.Batch(progressCmd, tea.Quit)
+		// Collapsing depends on the step's new status.
+		m.updateViewport()
+		return m, tea.Batch(m.setProgress(), cmd, m.waitForActivity())
+	case installCancelledMsg:
+		if m.err == nil {
+			m.err = msg.err
 		}
-
-		return m, tea.Batch(progressCmd, m.startCurrentStep())
+		return m, tea.Quit
 	}
 
 	var cmd tea.Cmd
@@ -138,117 +258,509 @@ func (m *installModel) View() string {
 	help := lipgloss.NewStyle().
 		Faint(true).
 		MarginTop(1).
-		Render("ctrl+c to cancel • arrows/pgup/pgdn to scroll logs")
+		Render(m.logHelp())
+
+	if len(m.failures) > 0 {
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			header,
+			m.progress.ViewAs(m.percent),
+			m.stepListView(),
+			m.failureView(),
+		)
+	}
 
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
 		header,
 		m.progress.ViewAs(m.percent),
+		m.stepListView(),
 		m.viewport.View(),
 		help,
 	)
 }
 
-func (m *installModel) appendHeader(title string) {
-	if m.logs.Len() > 0 {
-		m.logs.WriteString("\n")
+var stepStatusStyles = map[stepStatus]lipgloss.Style{
+	stepPending:   lipgloss.NewStyle().Faint(true),
+	stepRunning:   lipgloss.NewStyle().Foreground(lipgloss.Color("#c4b5fd")),
+	stepAwaiting:  lipgloss.NewStyle().Foreground(lipgloss.Color("#f87171")),
+	stepSucceeded: lipgloss.NewStyle().Foreground(lipgloss.Color("#4ade80")),
+	stepSkipped:   lipgloss.NewStyle().Foreground(lipgloss.Color("#facc15")),
+	stepFailed:    lipgloss.NewStyle().Foreground(lipgloss.Color("#f87171")),
+}
+
+// stepListView lists every step with its status and elapsed time.
+func (m *installModel) stepListView() string {
+	now := m.now()
+	titleWidth := 0
+	for _, step := range m.graph.steps {
+		titleWidth = max(titleWidth, lipgloss.Width(step.title))
+	}
+
+	lines := make([]string, len(m.graph.steps))
+	for i, step := range m.graph.steps {
+		status := m.graph.status[i]
+		icon := statusIcon(status)
+		if status == stepRunning {
+			icon = m.spinner.View()
+		}
+
+		line := fmt.Sprintf("%s %-*s", icon, titleWidth, step.title)
+		if d, ok := m.times.elapsed(i, now); ok {
+			line += "  " + formatElapsed(d)
+		}
+		lines[i] = stepStatusStyles[status].Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// failureLogLines is how much of the failed step's output the panel shows.
+const failureLogLines = 12
+
+func (m *installModel) failureView() string {
+	index := m.failures[0]
+	step := m.graph.steps[index]
+
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#f87171")).
+		Bold(true).
+		Render(fmt.Sprintf("✗ %s failed", step.title))
+
+	output := m.logs.since(index, m.attempt[index])
+	body := lipgloss.NewStyle().
+		Faint(true).
+		Render(tailLines(output, failureLogLines))
+
+	keys := []string{"r retry"}
+	if step.optional {
+		keys = append(keys, "s skip")
 	}
-	m.writeWrapped(fmt.Sprintf("## %s", title))
+	keys = append(keys, "q abort")
+
+	help := lipgloss.NewStyle().
+		Faint(true).
+		MarginTop(1).
+		Render(strings.Join(keys, " • "))
+
+	panel := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#f87171")).
+		Padding(1, 2).
+		Width(max(20, m.viewport.Width))
+
+	return panel.Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		m.stepErrs[index].Error(),
+		"",
+		body,
+		help,
+	))
 }
 
-func (m *installModel) appendChunk(body string) {
-	m.writeWrapped(body)
+// schedule starts every step the graph reports as ready and logs the ones it
+// skips.
+func (m *installModel) schedule() tea.Cmd {
+	var cmds []tea.Cmd
+	err := m.graph.schedule(
+		func(i int) {
+			m.appendHeader(i, m.graph.steps[i].title)
+			cmds = append(cmds, m.start(i))
+		},
+		func(i int, need string) {
+			m.appendHeader(i, m.graph.steps[i].title)
+			m.stepProgress[i] = 1
+			m.appendChunk(i, streamStatus, skipNotice(need))
+		},
+	)
+	if err != nil {
+		m.err = err
+		m.cancel()
+		return tea.Quit
+	}
+
+	cmds = append(cmds, m.setProgress())
+	if m.graph.done() {
+		cmds = append(cmds, tea.Quit)
+	}
+	return tea.Batch(cmds...)
 }
 
-func (m *installModel) updateViewport() {
-	m.viewport.SetContent(m.logs.String())
-	if m.viewport.Width > 0 && m.viewport.Height > 0 {
-		m.viewport.GotoBottom()
+// start runs step i in the background. Its output and result arrive as
+// stepChunkMsg and stepFinishedMsg.
+func (m *installModel) start(i int) tea.Cmd {
+	step := m.graph.steps[i]
+	m.attempt[i] = m.logs.len(i)
+	m.graph.status[i] = stepRunning
+	m.stepProgress[i] = 0
+	m.estimators[i] = newProgressEstimator(step.phases)
+	m.times.start(i, m.now())
+
+	ctx := withTerminal(m.ctx, &stepTerminal{
+		interactive: step.interactive,
+		cols:        m.viewport.Width - 4,
+		attach: func(input io.Writer) {
+			m.send(stepInputMsg{index: i, input: input})
+		},
+	})
+
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+		err := step.run(ctx, func(stream logStream, chunk string) {
+			m.send(stepChunkMsg{index: i, stream: stream, text: chunk})
+		})
+		m.send(stepFinishedMsg{index: i, err: err})
+	}()
+
+	return m.setProgress()
+}
+
+func (m *installModel) send(msg tea.Msg) {
+	select {
+	case m.events <- msg:
+	case <-m.ctx.Done():
+	}
+}
+
+// fail handles a failed step as installGraph.fail decides: it pauses on the
+// failure panel, skips the step, or aborts the install.
+func (m *installModel) fail(i int, err error) tea.Cmd {
+	switch m.graph.fail(i, m.interactive, m.ctx.Err() != nil) {
+	case failurePause:
+		m.stepErrs[i] = err
+		m.failures = append(m.failures, i)
+		return nil
+	case failureSkip:
+		return m.skip(i)
+	default:
+		if m.err == nil {
+			m.err = err
+		}
+		m.cancel()
+		return tea.Quit
 	}
 }
 
-func (m *installModel) writeWrapped(body string) {
-	text := body
-	width := m.viewport.Width - 4
-	if width > 0 {
-		text = wordwrap.String(body, width)
+func (m *installModel) handleFailureKey(key string) tea.Cmd {
+	index := m.failures[0]
+	step := m.graph.steps[index]
+	switch key {
+	case "r":
+		m.failures = m.failures[1:]
+		m.appendHeader(index, step.title+" (retry)")
+		return m.start(index)
+	case "s":
+		if !step.optional {
+			return nil
+		}
+		m.failures = m.failures[1:]
+		return m.skip(index)
+	case "q", "esc":
+		m.graph.status[index] = stepFailed
+		m.err = m.stepErrs[index]
+		m.cancel()
+		return tea.Quit
 	}
-	if !strings.HasSuffix(text, "\n") {
-		text += "\n"
+	return nil
+}
+
+// promptStep returns the earliest started interactive step that is running
+// and ready for input, or -1.
+func (m *installModel) promptStep() int {
+	for _, i := range m.logs.order {
+		if m.graph.status[i] == stepRunning && m.inputs[i] != nil {
+			return i
+		}
 	}
-	m.logs.WriteString(text)
+	return -1
+}
+
+// handleSearchKey edits the search query. Matches are highlighted as the
+// query is typed; enter keeps it and moves to the first match, esc drops it.
+func (m *installModel) handleSearchKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		m.searching = false
+		m.search.Blur()
+		m.match = -1
+		m.jumpToMatch(1)
+		return nil
+	case "esc":
+		m.searching = false
+		m.search.Blur()
+		m.setQuery("")
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.setQuery(m.search.Value())
+	return cmd
+}
+
+// handleLogKey handles the log viewer's keys and reports whether key was
+// one of them; anything else scrolls the viewport.
+func (m *installModel) handleLogKey(key string) (tea.Cmd, bool) {
+	switch key {
+	case "/":
+		m.searching = true
+		m.search.SetValue("")
+		return m.search.Focus(), true
+	case "n":
+		m.jumpToMatch(1)
+	case "N":
+		m.jumpToMatch(-1)
+	case "esc":
+		m.setQuery("")
+	case "e":
+		m.toggleFilter(filterStderr)
+	case "w":
+		m.toggleFilter(filterWarnings)
+	case "c":
+		m.logs.collapse = !m.logs.collapse
+		m.updateViewport()
+	case "p":
+		return m.openPager(), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+func (m *installModel) setQuery(query string) {
+	m.logs.query = query
+	m.match = -1
+	m.notice = ""
 	m.updateViewport()
 }
 
-func (m *installModel) startCurrentStep() tea.Cmd {
-	step := m.steps[m.current]
-	m.appendHeader(step.title)
-	m.stepProgress = 0
-	m.percent = m.currentPercent()
-	progressCmd := m.progress.SetPercent(m.percent)
+// toggleFilter switches the logs to filter, or back to all output when it
+// is already applied.
+func (m *installModel) toggleFilter(filter logFilter) {
+	if m.logs.filter == filter {
+		filter = filterAll
+	}
+	m.logs.filter = filter
+	m.updateViewport()
+}
 
-	m.chunks = make(chan string)
-	m.done = make(chan error, 1)
+// jumpToMatch scrolls to the next (delta 1) or previous (delta -1) search
+// match, wrapping around at either end.
+func (m *installModel) jumpToMatch(delta int) {
+	matches := m.logs.matches
+	if m.logs.query == "" {
+		return
+	}
+	if len(matches) == 0 {
+		m.notice = fmt.Sprintf("no matches for %q", m.logs.query)
+		return
+	}
+	m.notice = ""
+
+	switch {
+	case m.match < 0 && delta > 0:
+		m.match = 0
+	case m.match < 0:
+		m.match = len(matches) - 1
+	default:
+		m.match = (m.match + delta + len(matches)) % len(matches)
+	}
+	m.viewport.SetYOffset(matches[m.match] - m.viewport.Height/2)
+}
 
-	go func() {
-		err := step.run(m.ctx, func(chunk string) {
-			select {
-			case m.chunks <- chunk:
-			case <-m.ctx.Done():
-			}
-		})
-		close(m.chunks)
-		m.done <- err
-	}()
+// openPager shows the full, unfiltered log in $PAGER, falling back to less.
+func (m *installModel) openPager() tea.Cmd {
+	file, err := os.CreateTemp("", "create-ekko-app-*.log")
+	if err != nil {
+		m.notice = fmt.Sprintf("pager: %v", err)
+		return nil
+	}
+	_, err = file.WriteString(m.logs.plain())
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		m.notice = fmt.Sprintf("pager: %v", err)
+		return nil
+	}
 
-	return tea.Batch(progressCmd, m.waitForActivity())
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+	cmd := exec.Command(pager[0], append(pager[1:], file.Name())...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		os.Remove(file.Name())
+		return pagerClosedMsg{err: err}
+	})
+}
+
+// logHelp describes the log viewer's state and keys.
+func (m *installModel) logHelp() string {
+	if i := m.promptStep(); i >= 0 {
+		return fmt.Sprintf("keys are sent to %s • ctrl+c cancel", m.graph.steps[i].title)
+	}
+	if m.searching {
+		return m.search.View() + "\nenter search • esc cancel"
+	}
+
+	var state []string
+	if m.logs.filter != filterAll {
+		state = append(state, m.logs.filter.String())
+	}
+	if m.logs.collapse {
+		state = append(state, "completed steps collapsed")
+	}
+	if m.logs.query != "" {
+		position := "-"
+		if m.match >= 0 {
+			position = fmt.Sprint(m.match + 1)
+		}
+		state = append(state, fmt.Sprintf("/%s %s/%d", m.logs.query, position, len(m.logs.matches)))
+	}
+	if m.notice != "" {
+		state = append(state, m.notice)
+	}
+
+	keys := "ctrl+c cancel • / search • e stderr • w warnings • c collapse • p pager"
+	if m.logs.query != "" {
+		keys = "ctrl+c cancel • n/N next/prev match • esc clear search • e stderr • w warnings • c collapse • p pager"
+	}
+	if len(state) == 0 {
+		return keys
+	}
+	return strings.Join(state, " • ") + "\n" + keys
+}
+
+// skip logs step i's hint and marks it skipped. Steps that need it see it as
+// incomplete.
+func (m *installModel) skip(i int) tea.Cmd {
+	if hint := m.graph.steps[i].hint; hint != "" {
+		m.appendChunk(i, streamStatus, hint)
+	}
+	m.graph.status[i] = stepSkipped
+	m.updateViewport()
+	return m.schedule()
+}
+
+// stop cancels any running steps and waits for them to return.
+func (m *installModel) stop() {
+	m.cancel()
+	m.running.Wait()
 }
 
 func (m *installModel) waitForActivity() tea.Cmd {
 	return func() tea.Msg {
 		select {
-		case chunk, ok := <-m.chunks:
-			if !ok {
-				err := <-m.done
-				return stepFinishedMsg{err: err}
-			}
-			return stepChunkMsg{text: chunk}
-		case err := <-m.done:
-			return stepFinishedMsg{err: err}
+		case msg := <-m.events:
+			return msg
 		case <-m.ctx.Done():
-			return stepFinishedMsg{err: m.ctx.Err()}
+			return installCancelledMsg{err: m.ctx.Err()}
 		}
 	}
 }
 
+func (m *installModel) appendHeader(i int, title string) {
+	m.logs.header(i, title)
+	m.updateViewport()
+}
+
+func (m *installModel) appendChunk(i int, stream logStream, text string) {
+	m.logs.append(i, stream, text)
+	m.updateViewport()
+}
+
+// content renders the logs of all started steps as the viewport shows them.
+func (m *installModel) content() string {
+	return m.logs.render(m.viewport.Width-4, func(i int) bool {
+		status := m.graph.status[i]
+		return status == stepSucceeded || status == stepSkipped
+	})
+}
+
+// updateViewport re-renders the logs, following new output unless the
+// logs have been scrolled up.
+func (m *installModel) updateViewport() {
+	follow := m.viewport.AtBottom()
+	m.viewport.SetContent(m.content())
+	if follow && m.viewport.Width > 0 && m.viewport.Height > 0 {
+		m.viewport.GotoBottom()
+	}
+}
+
+// currentPercent averages the progress of every step in the graph,
+// weighted by how long each step is expected to take.
 func (m *installModel) currentPercent() float64 {
-	total := len(m.steps)
+	var sum, total float64
+	for i, status := range m.graph.status {
+		weight := m.graph.steps[i].weight
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+		if status.finished() {
+			sum += weight
+			continue
+		}
+		sum += weight * m.stepProgress[i]
+	}
 	if total == 0 {
 		return 1
 	}
-	if m.stepProgress < 0 {
-		m.stepProgress = 0
-	}
-	if m.stepProgress > 1 {
-		m.stepProgress = 1
-	}
-	return (float64(m.current) + m.stepProgress) / float64(total)
+	return sum / total
+}
+
+func (m *installModel) setProgress() tea.Cmd {
+	m.percent = m.currentPercent()
+	return m.progress.SetPercent(m.percent)
 }
 
-func (m *installModel) bumpStepProgress() tea.Cmd {
-	if len(m.steps) == 0 {
+// observeProgress updates step i's progress from a chunk of its output,
+// falling back to bumpStepProgress for output the estimator does not
+// recognise. A step only reaches 100% once it has finished.
+func (m *installModel) observeProgress(i int, text string) tea.Cmd {
+	estimator := m.estimators[i]
+	moved := false
+	for _, line := range strings.Split(text, "\n") {
+		if value, ok := estimator.observe(line); ok {
+			m.stepProgress[i] = minFloat(value, maxDuringStep)
+			moved = true
+		}
+	}
+	switch {
+	case moved:
+		return m.setProgress()
+	case estimator.parsed:
 		return nil
+	default:
+		return m.bumpStepProgress(i)
 	}
+}
+
+// maxDuringStep caps the progress of a step that has not finished yet.
+const maxDuringStep = 0.99
+
+func (m *installModel) bumpStepProgress(i int) tea.Cmd {
 	const (
-		chunkStep     = 0.05
-		maxDuringStep = 0.9
+		chunkStep = 0.05
+		maxBumped = 0.9
 	)
-	if m.stepProgress >= maxDuringStep {
+	if m.stepProgress[i] >= maxBumped {
 		return nil
 	}
-	m.stepProgress = minFloat(maxDuringStep, m.stepProgress+chunkStep)
-	m.percent = m.currentPercent()
-	return m.progress.SetPercent(m.percent)
+	m.stepProgress[i] = minFloat(maxBumped, m.stepProgress[i]+chunkStep)
+	return m.setProgress()
+}
+
+// tailLines returns the last n lines of text.
+func tailLines(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
 }
 
 func max(a, b int) int {
@@ -0,0 +1,526 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/mikekenway/create-ekko-app/internal/testutil/golden"
+)
+
+func TestMain(m *testing.M) {
+	// Views are snapshotted without colour, whatever terminal runs the tests.
+	lipgloss.SetColorProfile(termenv.Ascii)
+	os.Exit(m.Run())
+}
+
+var testClock = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func keyMsg(key string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func startInstallModel(t *testing.T, steps []installStep, interactive bool) *installModel {
+	t.Helper()
+	graph, err := newInstallGraph(steps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newInstallModel(context.Background(), graph)
+	m.interactive = interactive
+	// A fixed clock keeps elapsed times, and so the views, stable.
+	m.now = func() time.Time { return testClock }
+	t.Cleanup(m.stop)
+	m.Init()
+	return m
+}
+
+// settle feeds step events to the model until nothing is running.
+func settle(t *testing.T, m *installModel) {
+	t.Helper()
+	for m.graph.count(stepRunning) > 0 {
+		select {
+		case msg := <-m.events:
+			m.Update(msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for steps")
+		}
+	}
+}
+
+func TestInstallModelRetriesFailedStep(t *testing.T) {
+	attempts := 0
+	m := startInstallModel(t, []installStep{{
+		id:    "flaky",
+		title: "flaky",
+		run: func(_ context.Context, write outputFunc) error {
+			attempts++
+			write(streamStdout, "attempt\n")
+			if attempts == 1 {
+				return errors.New("network down")
+			}
+			return nil
+		},
+	}}, true)
+	settle(t, m)
+
+	if len(m.failures) != 1 {
+		t.Fatal("expected the model to pause on the failure")
+	}
+	if view := m.View(); !strings.Contains(view, "r retry") || strings.Contains(view, "s skip") {
+		t.Fatalf("unexpected failure panel:\n%s", view)
+	}
+
+	m.Update(keyMsg("r"))
+	settle(t, m)
+
+	if attempts != 2 || m.err != nil || !m.graph.done() {
+		t.Fatalf("attempts=%d err=%v statuses=%v", attempts, m.err, m.graph.status)
+	}
+}
+
+func TestInstallModelSkipsOnlyOptionalSteps(t *testing.T) {
+	m := startInstallModel(t, []installStep{
+		{
+			id:       "init",
+			title:    "shadcn init",
+			optional: true,
+			hint:     "rerun shadcn later",
+			run:      func(context.Context, outputFunc) error { return errors.New("boom") },
+		},
+		{
+			id:       "add",
+			title:    "shadcn add",
+			needs:    []string{"init"},
+			optional: true,
+			run: func(context.Context, outputFunc) error {
+				t.Error("a step whose need was skipped must not run")
+				return nil
+			},
+		},
+	}, true)
+	settle(t, m)
+
+	m.Update(keyMsg("s"))
+	settle(t, m)
+
+	if m.err != nil || !m.graph.done() || m.graph.count(stepSkipped) != 2 {
+		t.Fatalf("err=%v statuses=%v", m.err, m.graph.status)
+	}
+	if logs := m.content(); !strings.Contains(logs, "rerun shadcn later") || !strings.Contains(logs, "Skipping because") {
+		t.Fatalf("expected hint and skip notice in the logs:\n%s", logs)
+	}
+}
+
+func TestInstallModelAbort(t *testing.T) {
+	failure := errors.New("install failed")
+	m := startInstallModel(t, []installStep{{
+		id:    "deps",
+		title: "deps",
+		run:   func(context.Context, outputFunc) error { return failure },
+	}}, true)
+	settle(t, m)
+
+	m.Update(keyMsg("s"))
+	if len(m.failures) == 0 {
+		t.Fatal("required steps must not be skippable")
+	}
+
+	if _, cmd := m.Update(keyMsg("q")); cmd == nil {
+		t.Fatal("expected abort to quit")
+	}
+	if !errors.Is(m.err, failure) {
+		t.Fatalf("expected the step error, got %v", m.err)
+	}
+}
+
+func TestInstallModelNonInteractiveSkipsOptional(t *testing.T) {
+	m := startInstallModel(t, []installStep{{
+		id:       "shadcn",
+		title:    "shadcn",
+		optional: true,
+		run:      func(context.Context, outputFunc) error { return errors.New("boom") },
+	}}, false)
+	settle(t, m)
+
+	if len(m.failures) != 0 || m.err != nil || !m.graph.done() {
+		t.Fatalf("failures=%v err=%v statuses=%v", m.failures, m.err, m.graph.status)
+	}
+}
+
+func TestInstallModelRunsIndependentStepsConcurrently(t *testing.T) {
+	var both sync.WaitGroup
+	both.Add(2)
+	// Each step waits for the other to start, so they deadlock unless they
+	// run at the same time.
+	meet := func(context.Context, outputFunc) error {
+		both.Done()
+		both.Wait()
+		return nil
+	}
+
+	m := startInstallModel(t, []installStep{
+		{id: "framework", title: "framework", run: func(context.Context, outputFunc) error { return nil }},
+		{id: "deps", title: "deps", needs: []string{"framework"}, run: meet},
+		{id: "env", title: "env", needs: []string{"framework"}, run: meet},
+	}, false)
+	settle(t, m)
+
+	if !m.graph.done() || m.err != nil {
+		t.Fatalf("err=%v statuses=%v", m.err, m.graph.status)
+	}
+	if m.percent != 1 {
+		t.Fatalf("expected full progress, got %v", m.percent)
+	}
+}
+
+func TestInstallGraphRejectsForwardNeeds(t *testing.T) {
+	_, err := newInstallGraph([]installStep{
+		{id: "a", needs: []string{"b"}},
+		{id: "b"},
+	}, nil)
+	if err == nil {
+		t.Fatal("expected a forward need to be rejected")
+	}
+
+	graph, err := newInstallGraph([]installStep{{id: "deps", needs: []string{"framework"}}}, []string{"framework"})
+	if err != nil {
+		t.Fatalf("needs completed by an earlier run must be allowed: %v", err)
+	}
+	if ready := graph.ready(); len(ready) != 1 {
+		t.Fatalf("expected deps to be ready, got %v", ready)
+	}
+}
+
+func TestInstallGraphRejectsUnknownNeeds(t *testing.T) {
+	_, err := newInstallGraph([]installStep{
+		{id: "framework"},
+		{id: "deps", needs: []string{"framwork"}},
+	}, []string{"directory"})
+	if err == nil || !strings.Contains(err.Error(), "framwork") {
+		t.Fatalf("expected a misspelled need to be rejected, got %v", err)
+	}
+}
+
+func TestInstallModelLogKeys(t *testing.T) {
+	m := startInstallModel(t, []installStep{{
+		id:    "deps",
+		title: "Install deps",
+		run: func(_ context.Context, write outputFunc) error {
+			write(streamStdout, "Packages: +12\n")
+			write(streamStderr, " WARN  deprecated inflight\n")
+			write(streamStdout, "warn again\n")
+			return nil
+		},
+	}}, false)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	settle(t, m)
+
+	m.Update(keyMsg("e"))
+	if logs := m.content(); strings.Contains(logs, "Packages") || !strings.Contains(logs, "inflight") {
+		t.Fatalf("expected only stderr:\n%s", logs)
+	}
+	m.Update(keyMsg("e"))
+	if logs := m.content(); !strings.Contains(logs, "Packages") {
+		t.Fatalf("pressing e again must show all output:\n%s", logs)
+	}
+
+	m.Update(keyMsg("/"))
+	for _, r := range "warn" {
+		m.Update(keyMsg(string(r)))
+	}
+	m.Update(keyMsg("n"))
+	if m.logs.query != "warnn" || !m.searching {
+		t.Fatalf("keys typed while searching belong to the query, got %q", m.logs.query)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.searching || m.match != 0 || len(m.logs.matches) != 2 {
+		t.Fatalf("searching=%v match=%d matches=%v", m.searching, m.match, m.logs.matches)
+	}
+	m.Update(keyMsg("n"))
+	m.Update(keyMsg("n"))
+	if m.match != 0 {
+		t.Fatalf("n must wrap around to the first match, got %d", m.match)
+	}
+	m.Update(keyMsg("N"))
+	if m.match != 1 {
+		t.Fatalf("N must wrap around to the last match, got %d", m.match)
+	}
+	if help := m.logHelp(); !strings.Contains(help, "/warn 2/2") {
+		t.Fatalf("expected the search position in the help line:\n%s", help)
+	}
+
+	m.Update(keyMsg("c"))
+	if logs := m.content(); logs != "## Install deps ▸ 3 lines" {
+		t.Fatalf("expected the finished step to collapse, got:\n%s", logs)
+	}
+}
+
+type keyBuffer chan string
+
+func (k keyBuffer) Write(p []byte) (int, error) {
+	k <- string(p)
+	return len(p), nil
+}
+
+func TestInstallModelForwardsKeysToInteractiveStep(t *testing.T) {
+	answer := make(chan string, 1)
+	m := startInstallModel(t, []installStep{{
+		id:          "shadcn",
+		title:       "Initialize shadcn",
+		interactive: true,
+		run: func(ctx context.Context, write outputFunc) error {
+			term := terminalFrom(ctx)
+			if term == nil || !term.interactive {
+				return errors.New("expected an interactive terminal")
+			}
+			// Like a terminal, the input is buffered.
+			input := keyBuffer(make(chan string, 8))
+			term.attach(input)
+			write(streamStdout, "? Proceed? ")
+			answer <- <-input + <-input
+			return nil
+		},
+	}}, false)
+
+	// Wait for the step to hand over its input.
+	for m.promptStep() < 0 {
+		select {
+		case msg := <-m.events:
+			m.Update(msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the step's input")
+		}
+	}
+	if help := m.logHelp(); !strings.Contains(help, "keys are sent to Initialize shadcn") {
+		t.Fatalf("unexpected help: %s", help)
+	}
+
+	// Log viewer keys go to the step while it is prompting.
+	m.Update(keyMsg("c"))
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	settle(t, m)
+
+	if got := <-answer; got != "c\r" {
+		t.Fatalf("step read %q", got)
+	}
+	if m.logs.collapse || m.err != nil || m.promptStep() >= 0 {
+		t.Fatalf("collapse=%v err=%v", m.logs.collapse, m.err)
+	}
+}
+
+func quits(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func TestInstallModelViewportSizing(t *testing.T) {
+	tests := []struct {
+		width, height int
+		vpWidth       int
+		vpHeight      int
+	}{
+		// Half the height, capped on tall terminals...
+		{width: 120, height: 200, vpWidth: 116, vpHeight: 18},
+		{width: 80, height: 30, vpWidth: 76, vpHeight: 11},
+		// ...with a floor on short and narrow ones.
+		{width: 10, height: 10, vpWidth: 20, vpHeight: 8},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%dx%d", tt.width, tt.height), func(t *testing.T) {
+			graph, err := newInstallGraph(nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := newInstallModel(context.Background(), graph)
+			m.Update(tea.WindowSizeMsg{Width: tt.width, Height: tt.height})
+			if m.viewport.Width != tt.vpWidth || m.viewport.Height != tt.vpHeight || m.progress.Width != tt.vpWidth {
+				t.Fatalf("viewport %dx%d, progress %d", m.viewport.Width, m.viewport.Height, m.progress.Width)
+			}
+		})
+	}
+}
+
+func TestInstallModelCtrlCDuringInstall(t *testing.T) {
+	started := make(chan struct{})
+	m := startInstallModel(t, []installStep{
+		{
+			id:    "deps",
+			title: "Install dependencies",
+			run: func(ctx context.Context, write outputFunc) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+		{
+			id:    "shadcn",
+			title: "Initialize shadcn",
+			needs: []string{"deps"},
+			run: func(context.Context, outputFunc) error {
+				t.Error("no step may start after ctrl+c")
+				return nil
+			},
+		},
+	}, true)
+	<-started
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !quits(cmd) || !errors.Is(m.err, ErrInstallCancelled) {
+		t.Fatalf("quits=%v err=%v", quits(cmd), m.err)
+	}
+	if m.ctx.Err() == nil {
+		t.Fatal("ctrl+c must cancel running steps")
+	}
+	m.stop()
+
+	// The step's result is dropped once cancelled; the summary shows it was
+	// cut short.
+	var summary strings.Builder
+	if err := writeTimingSummary(&summary, m.graph, m.times, m.now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.String(), "✗ interrupted") || !strings.Contains(summary.String(), "○ not run") {
+		t.Fatalf("unexpected summary:\n%s", summary.String())
+	}
+}
+
+func TestInstallModelNonInteractiveFailureAborts(t *testing.T) {
+	failure := errors.New("exit status 1")
+	m := startInstallModel(t, []installStep{
+		{
+			id:    "framework",
+			title: "Create Next.js project",
+			run: func(_ context.Context, write outputFunc) error {
+				write(streamStderr, "ERR_PNPM_FETCH_404\n")
+				return failure
+			},
+		},
+		{
+			id:    "deps",
+			title: "Install dependencies",
+			needs: []string{"framework"},
+			run: func(context.Context, outputFunc) error {
+				t.Error("a step whose need failed must not run")
+				return nil
+			},
+		},
+	}, false)
+
+	msg := <-m.events
+	m.Update(msg)
+	msg = <-m.events
+	_, cmd := m.Update(msg)
+	if !errors.Is(m.err, failure) || m.graph.status[0] != stepFailed || len(m.failures) != 0 {
+		t.Fatalf("err=%v statuses=%v failures=%v", m.err, m.graph.status, m.failures)
+	}
+	// tea.Batch wraps the quit; run it to find the QuitMsg.
+	if !batchQuits(cmd) {
+		t.Fatal("a failed required step must end the install")
+	}
+}
+
+// batchQuits reports whether cmd, or any command it batches, quits.
+// Commands that would block waiting for step events are not run.
+func batchQuits(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+	select {
+	case msg := <-done:
+		switch msg := msg.(type) {
+		case tea.QuitMsg:
+			return true
+		case tea.BatchMsg:
+			for _, c := range msg {
+				if batchQuits(c) {
+					return true
+				}
+			}
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+	return false
+}
+
+// snapshotSteps finishes framework and env, leaving deps to fail.
+func snapshotSteps() []installStep {
+	return []installStep{
+		{
+			id:     "framework",
+			title:  "Create Next.js project",
+			weight: weightFramework,
+			run: func(_ context.Context, write outputFunc) error {
+				write(streamStdout, "Creating a new Next.js app in /work/app.\n")
+				write(streamStdout, "Success! Created app at /work/app\n")
+				return nil
+			},
+		},
+		{
+			id:     "deps",
+			title:  "Install selected dependencies",
+			needs:  []string{"framework"},
+			weight: weightDependencies,
+			run: func(_ context.Context, write outputFunc) error {
+				write(streamStderr, " ERR_PNPM_FETCH_404  GET https://registry.npmjs.org/@clerk%2fnextjs: Not Found - 404\n")
+				return errors.New("run pnpm add @clerk/nextjs: exit status 1")
+			},
+		},
+		{
+			id:    "versions",
+			title: "Record package versions",
+			needs: []string{"framework"},
+			run: func(_ context.Context, write outputFunc) error {
+				write(streamStatus, "$ write ekko.versions.json\n")
+				return nil
+			},
+		},
+	}
+}
+
+func TestInstallModelView(t *testing.T) {
+	for _, width := range []int{80, 120} {
+		t.Run(fmt.Sprint(width), func(t *testing.T) {
+			m := startInstallModel(t, snapshotSteps(), true)
+			m.Update(tea.WindowSizeMsg{Width: width, Height: 40})
+			settle(t, m)
+			golden.Check(t, fmt.Sprintf("views/install-failure-%d.golden", width), m.View())
+
+			m.Update(keyMsg("q"))
+			golden.Check(t, fmt.Sprintf("views/install-aborted-%d.golden", width), m.View())
+		})
+	}
+}
+
+func TestInstallModelViewFinished(t *testing.T) {
+	steps := snapshotSteps()
+	steps[1].run = func(_ context.Context, write outputFunc) error {
+		write(streamStdout, "Packages: +1\nProgress: resolved 1, reused 1, downloaded 0, added 1, done\n")
+		return nil
+	}
+	m := startInstallModel(t, steps, true)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	settle(t, m)
+	if !m.graph.done() || m.percent != 1 {
+		t.Fatalf("statuses=%v percent=%v", m.graph.status, m.percent)
+	}
+	golden.Check(t, "views/install-finished-80.golden", m.View())
+
+	m.Update(keyMsg("c"))
+	golden.Check(t, "views/install-collapsed-80.golden", m.View())
+}
@@ -0,0 +1,234 @@
+package scaffold
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// installLogFile is the name of the install log inside StateDir.
+const installLogFile = "install.log"
+
+// installLog records every step attempt's header and output with
+// timestamps. A run that starts before its project exists writes to a
+// temporary file and moves it into .ekko/install.log once the project has
+// been created (see projectStep). Logs are appended to, so a resumed run
+// continues the log of the one it picks up from. A nil *installLog discards
+// everything.
+type installLog struct {
+	mu   sync.Mutex
+	file *os.File
+	// target is where the log moves once the project exists; empty when it
+	// already lives there or --log-file fixed its location.
+	target string
+}
+
+// openInstallLog opens override when set, the project's log when the
+// project is ready, and a temporary file otherwise.
+func openInstallLog(override, projectPath string, projectReady bool) (*installLog, error) {
+	if override != "" {
+		file, err := openAppend(override)
+		if err != nil {
+			return nil, err
+		}
+		return &installLog{file: file}, nil
+	}
+
+	target := filepath.Join(projectPath, StateDir, installLogFile)
+	if projectReady {
+		file, err := openAppend(target)
+		if err != nil {
+			return nil, err
+		}
+		return &installLog{file: file}, nil
+	}
+
+	file, err := os.CreateTemp("", "create-ekko-app-*.log")
+	if err != nil {
+		return nil, fmt.Errorf("create install log: %w", err)
+	}
+	return &installLog{file: file, target: target}, nil
+}
+
+func openAppend(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create install log: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("create install log: %w", err)
+	}
+	return file, nil
+}
+
+// Path returns where the log is currently written.
+func (l *installLog) Path() string {
+	if l == nil {
+		return ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Name()
+}
+
+// tee logs a header for a new attempt at step id and returns an outputFunc
+// that records each line before passing it on to write.
+func (l *installLog) tee(id, title string, write outputFunc) outputFunc {
+	if l == nil {
+		return write
+	}
+	l.writeLines(id, "##", title)
+	return func(stream logStream, text string) {
+		l.writeLines(id, string(stream), text)
+		write(stream, text)
+	}
+}
+
+// result records how an attempt at step id ended.
+func (l *installLog) result(id string, err error) {
+	if l == nil {
+		return
+	}
+	if err != nil {
+		l.writeLines(id, string(streamStatus), "✗ failed: "+err.Error())
+		return
+	}
+	l.writeLines(id, string(streamStatus), "✓ succeeded")
+}
+
+func (l *installLog) writeLines(stepID, label, text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stamp := time.Now().Format("2006-01-02T15:04:05.000Z07:00")
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintf(&b, "%s %s %s %s\n", stamp, stepID, label, line)
+	}
+	// Logging is best effort and must never fail a step.
+	_, _ = io.WriteString(l.file, b.String())
+}
+
+// moveIntoProject continues the log at .ekko/install.log, carrying over
+// what has been written so far. It does nothing once the log has moved or
+// when its location was given explicitly.
+func (l *installLog) moveIntoProject() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.target == "" {
+		return nil
+	}
+	file, err := openAppend(l.target)
+	if err != nil {
+		return err
+	}
+	if err := l.switchTo(file); err != nil {
+		return err
+	}
+	l.target = ""
+	return nil
+}
+
+// moveOutOf relocates the log to a temporary file when it lives under one
+// of paths, so a rollback does not delete it.
+func (l *installLog) moveOutOf(paths []string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inside := false
+	for _, path := range paths {
+		if rel, err := filepath.Rel(path, l.file.Name()); err == nil && !strings.HasPrefix(rel, "..") {
+			inside = true
+			break
+		}
+	}
+	if !inside {
+		return nil
+	}
+
+	file, err := os.CreateTemp("", "create-ekko-app-*.log")
+	if err != nil {
+		return fmt.Errorf("move install log: %w", err)
+	}
+	if err := l.switchTo(file); err != nil {
+		return err
+	}
+	l.target = ""
+	return nil
+}
+
+// switchTo copies the current log into file, which becomes the log, and
+// removes the old one.
+func (l *installLog) switchTo(file *os.File) error {
+	old := l.file.Name()
+	src, err := os.Open(old)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("move install log: %w", err)
+	}
+	defer src.Close()
+
+	if _, err := io.Copy(file, src); err != nil {
+		file.Close()
+		return fmt.Errorf("move install log: %w", err)
+	}
+
+	l.file.Close()
+	os.Remove(old)
+	l.file = file
+	return nil
+}
+
+// Close closes the log file.
+func (l *installLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// ignoreStateDir adds StateDir to the project's .gitignore, creating the
+// file if needed, unless a line already ignores it.
+func ignoreStateDir(projectPath string) error {
+	path := filepath.Join(projectPath, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Trim(strings.TrimSpace(line), "/") == StateDir {
+			return nil
+		}
+	}
+
+	var b strings.Builder
+	if len(data) > 0 {
+		if !strings.HasSuffix(string(data), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "# create-ekko-app install log\n/%s/\n", StateDir)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(b.String()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,142 @@
+package scaffold
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallLogMovesIntoProject(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "demo")
+
+	log, err := openInstallLog("", project, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	temp := log.Path()
+	if strings.HasPrefix(temp, project) {
+		t.Fatalf("log must not be created inside the project before it exists: %s", temp)
+	}
+
+	var forwarded []string
+	write := log.tee(stepFramework, "Create Next.js project", func(stream logStream, text string) {
+		forwarded = append(forwarded, string(stream)+":"+text)
+	})
+	write(streamStdout, "Creating a new Next.js app\n")
+	log.result(stepFramework, nil)
+
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.moveIntoProject(); err != nil {
+		t.Fatalf("moveIntoProject: %v", err)
+	}
+
+	write = log.tee(stepDependencies, "Install selected dependencies", func(logStream, string) {})
+	write(streamStderr, "ERR_PNPM_FETCH_404\n")
+	log.result(stepDependencies, errors.New("exit status 1"))
+
+	want := filepath.Join(project, StateDir, installLogFile)
+	if log.Path() != want {
+		t.Fatalf("log path = %s, want %s", log.Path(), want)
+	}
+	if _, err := os.Stat(temp); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary log to be removed, got %v", err)
+	}
+	if len(forwarded) != 1 || forwarded[0] != "stdout:Creating a new Next.js app\n" {
+		t.Fatalf("expected output to be passed on, got %q", forwarded)
+	}
+
+	data, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		" framework ## Create Next.js project\n",
+		" framework stdout Creating a new Next.js app\n",
+		" framework status ✓ succeeded\n",
+		" dependencies stderr ERR_PNPM_FETCH_404\n",
+		" dependencies status ✗ failed: exit status 1\n",
+	} {
+		if !strings.Contains(string(data), line) {
+			t.Fatalf("expected %q in log:\n%s", line, data)
+		}
+	}
+}
+
+func TestInstallLogSurvivesRollback(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "demo")
+
+	log, err := openInstallLog("", project, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	log.result(stepFramework, nil)
+
+	if err := log.moveOutOf([]string{project}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(project); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(log.Path())
+
+	data, err := os.ReadFile(log.Path())
+	if err != nil {
+		t.Fatalf("log was removed with the project: %v", err)
+	}
+	if !strings.Contains(string(data), "✓ succeeded") {
+		t.Fatalf("expected earlier entries to be kept:\n%s", data)
+	}
+}
+
+func TestInstallLogOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "ekko.log")
+
+	log, err := openInstallLog(path, "/nonexistent/demo", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	if err := log.moveIntoProject(); err != nil {
+		t.Fatal(err)
+	}
+	if log.Path() != path {
+		t.Fatalf("--log-file must never move, got %s", log.Path())
+	}
+}
+
+func TestIgnoreStateDir(t *testing.T) {
+	project := t.TempDir()
+	path := filepath.Join(project, ".gitignore")
+	if err := os.WriteFile(path, []byte("node_modules"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := ignoreStateDir(project); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "node_modules\n\n# create-ekko-app install log\n/.ekko/\n"; string(data) != want {
+		t.Fatalf(".gitignore = %q, want %q", data, want)
+	}
+
+	if err := os.WriteFile(path, []byte(".ekko\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ignoreStateDir(project); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != ".ekko\n" {
+		t.Fatalf("an existing entry must be left alone, got %q", data)
+	}
+}
@@ -0,0 +1,227 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// logFilter limits which output lines the install log shows.
+type logFilter int
+
+const (
+	filterAll logFilter = iota
+	filterStderr
+	filterWarnings
+)
+
+func (f logFilter) String() string {
+	switch f {
+	case filterStderr:
+		return "stderr only"
+	case filterWarnings:
+		return "warnings only"
+	default:
+		return "all output"
+	}
+}
+
+// warningMarkers flag a line as a warning for filterWarnings.
+var warningMarkers = []string{"WARN", "ERR_PNPM", "⚠️"}
+
+func isWarning(text string) bool {
+	for _, marker := range warningMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+type logLine struct {
+	stream logStream
+	// text keeps a trailing carriage return, which the next text written to
+	// an open line overwrites; see overwrite.
+	text string
+	// header lines start a step attempt and are always shown.
+	header bool
+}
+
+func (l logLine) String() string {
+	return strings.TrimRight(l.text, "\r")
+}
+
+var searchMatchStyle = lipgloss.NewStyle().
+	Background(lipgloss.Color("#f472b6")).
+	Foreground(lipgloss.Color("#1e1b4b"))
+
+// logView holds the install output of every step, one section per step in
+// the order they started, and renders it with the current filter, search
+// and collapsing applied.
+type logView struct {
+	lines [][]logLine
+	order []int
+	// open marks steps whose last line has not ended yet, such as a prompt
+	// waiting for an answer.
+	open []bool
+
+	filter   logFilter
+	collapse bool
+	query    string
+	// matches lists the rendered lines that contain query, as of the last
+	// render.
+	matches []int
+}
+
+func newLogView(steps int) *logView {
+	return &logView{lines: make([][]logLine, steps), open: make([]bool, steps)}
+}
+
+// header starts a new attempt at step i.
+func (v *logView) header(i int, title string) {
+	if len(v.lines[i]) == 0 {
+		v.order = append(v.order, i)
+	}
+	v.lines[i] = append(v.lines[i], logLine{stream: streamStatus, text: "## " + title, header: true})
+	v.open[i] = false
+}
+
+// append adds text, which may hold several lines, to step i. Text without
+// a trailing newline leaves its last line open, and the next text from the
+// same stream continues it.
+func (v *logView) append(i int, stream logStream, text string) {
+	if text == "" {
+		return
+	}
+	ended := strings.HasSuffix(text, "\n")
+	for n, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		last := len(v.lines[i]) - 1
+		if n == 0 && v.open[i] && v.lines[i][last].stream == stream {
+			v.lines[i][last].text = overwrite(v.lines[i][last].text + line)
+			continue
+		}
+		v.lines[i] = append(v.lines[i], logLine{stream: stream, text: overwrite(line)})
+	}
+	v.open[i] = !ended
+}
+
+// overwrite applies carriage returns the way a terminal would for a line
+// that is redrawn in place: only what follows the last one remains.
+func overwrite(line string) string {
+	if at := strings.LastIndexByte(strings.TrimRight(line, "\r"), '\r'); at >= 0 {
+		return line[at+1:]
+	}
+	return line
+}
+
+// len returns how many lines step i has logged.
+func (v *logView) len(i int) int {
+	return len(v.lines[i])
+}
+
+// since returns step i's lines from index from on, as plain text.
+func (v *logView) since(i, from int) string {
+	texts := make([]string, 0, len(v.lines[i])-from)
+	for _, line := range v.lines[i][from:] {
+		texts = append(texts, line.String())
+	}
+	return strings.Join(texts, "\n")
+}
+
+// plain returns the whole log without filtering, for the pager.
+func (v *logView) plain() string {
+	var b strings.Builder
+	for n, i := range v.order {
+		if n > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range v.lines[i] {
+			b.WriteString(line.String())
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func (v *logView) shows(line logLine) bool {
+	switch {
+	case line.header:
+		return true
+	case v.filter == filterStderr:
+		return line.stream == streamStderr
+	case v.filter == filterWarnings:
+		return isWarning(line.String())
+	default:
+		return true
+	}
+}
+
+// render lays the log out at width. Steps for which completed returns true
+// are reduced to their header while collapsing is on.
+func (v *logView) render(width int, completed func(i int) bool) string {
+	v.matches = v.matches[:0]
+	query := strings.ToLower(v.query)
+
+	var out []string
+	for n, i := range v.order {
+		if n > 0 {
+			out = append(out, "")
+		}
+
+		if v.collapse && completed(i) {
+			last := v.lines[i][0]
+			hidden := 0
+			for _, line := range v.lines[i] {
+				if line.header {
+					last = line
+				} else {
+					hidden++
+				}
+			}
+			out = v.appendRendered(out, fmt.Sprintf("%s ▸ %d lines", last.text, hidden), width, query)
+			continue
+		}
+
+		for _, line := range v.lines[i] {
+			if v.shows(line) {
+				out = v.appendRendered(out, line.String(), width, query)
+			}
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func (v *logView) appendRendered(out []string, text string, width int, query string) []string {
+	wrapped := text
+	if width > 0 {
+		wrapped = wordwrap.String(text, width)
+	}
+	for _, line := range strings.Split(wrapped, "\n") {
+		if query != "" && strings.Contains(strings.ToLower(line), query) {
+			v.matches = append(v.matches, len(out))
+			line = highlight(line, query)
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// highlight marks every case-insensitive occurrence of query in line.
+func highlight(line, query string) string {
+	lower := strings.ToLower(line)
+	var b strings.Builder
+	for {
+		at := strings.Index(lower, query)
+		// Lowercasing can change byte lengths outside ASCII; leave such lines
+		// unhighlighted rather than slicing them in the wrong place.
+		if at < 0 || len(lower) != len(line) {
+			b.WriteString(line)
+			return b.String()
+		}
+		b.WriteString(line[:at])
+		b.WriteString(searchMatchStyle.Render(line[at : at+len(query)]))
+		line, lower = line[at+len(query):], lower[at+len(query):]
+	}
+}
@@ -0,0 +1,107 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+)
+
+func sampleLogView() *logView {
+	v := newLogView(2)
+	v.header(0, "Create app")
+	v.append(0, streamStdout, "Progress: resolved 10\n")
+	v.append(0, streamStderr, " WARN  deprecated glob@7\nnoise on stderr\n")
+	v.header(1, "Install deps")
+	v.append(1, streamStdout, "ERR_PNPM_FETCH_404 not found\ndone\n")
+	return v
+}
+
+func neverCompleted(int) bool { return false }
+
+func TestLogViewFilters(t *testing.T) {
+	tests := []struct {
+		filter logFilter
+		want   string
+	}{
+		{filterAll, "## Create app\nProgress: resolved 10\n WARN  deprecated glob@7\nnoise on stderr\n\n## Install deps\nERR_PNPM_FETCH_404 not found\ndone"},
+		{filterStderr, "## Create app\n WARN  deprecated glob@7\nnoise on stderr\n\n## Install deps"},
+		{filterWarnings, "## Create app\n WARN  deprecated glob@7\n\n## Install deps\nERR_PNPM_FETCH_404 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter.String(), func(t *testing.T) {
+			v := sampleLogView()
+			v.filter = tt.filter
+			if got := v.render(0, neverCompleted); got != tt.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogViewCollapsesCompletedSteps(t *testing.T) {
+	v := sampleLogView()
+	v.header(0, "Create app (retry)")
+	v.append(0, streamStdout, "ok\n")
+	v.collapse = true
+
+	got := v.render(0, func(i int) bool { return i == 0 })
+	want := "## Create app (retry) ▸ 4 lines\n\n## Install deps\nERR_PNPM_FETCH_404 not found\ndone"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLogViewSearchMatches(t *testing.T) {
+	v := sampleLogView()
+	v.query = "warn"
+	v.render(0, neverCompleted)
+	if len(v.matches) != 1 || v.matches[0] != 2 {
+		t.Fatalf("matches = %v, want [2]", v.matches)
+	}
+
+	// Matches are counted on wrapped lines.
+	v.query = "glob"
+	v.render(10, neverCompleted)
+	if len(v.matches) != 1 || v.matches[0] != 7 {
+		t.Fatalf("matches = %v, want [7]", v.matches)
+	}
+}
+
+func TestHighlightKeepsText(t *testing.T) {
+	for _, line := range []string{"WARN one warn two", "naïve WARN", "nothing"} {
+		if got := stripANSI(highlight(line, "warn")); got != line {
+			t.Fatalf("highlight(%q) = %q", line, got)
+		}
+	}
+}
+
+func stripANSI(s string) string {
+	var b strings.Builder
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			inEscape = true
+		case inEscape && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'):
+			inEscape = false
+		case !inEscape:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func TestLogViewContinuesOpenLines(t *testing.T) {
+	v := newLogView(1)
+	v.header(0, "Initialize shadcn")
+	v.append(0, streamStdout, "? Which color? ")
+	v.append(0, streamStderr, "warning on stderr\n")
+	v.append(0, streamStdout, "\rNeutral\n")
+	v.append(0, streamStdout, "- Installing 10%\r- Installing 80%\r")
+	v.append(0, streamStdout, "- Installing 100%\n")
+
+	got := v.render(0, neverCompleted)
+	want := "## Initialize shadcn\n? Which color? \nwarning on stderr\nNeutral\n- Installing 100%"
+	if got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
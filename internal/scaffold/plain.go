@@ -0,0 +1,226 @@
+package scaffold
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runInstall executes graph with the interactive view, as plain text when
+// opts.Plain is set, or through report when one is given.
+func runInstall(ctx context.Context, graph *installGraph, opts RunOptions, report installReporter) error {
+	if report == nil && !opts.Plain {
+		return runInstallUI(ctx, graph, opts.Interactive)
+	}
+
+	stdin := newStdinTerminal()
+	if report != nil {
+		return runHeadless(ctx, graph, report, stdin)
+	}
+
+	github := os.Getenv("GITHUB_ACTIONS") == "true"
+	plain := newPlainReporter(os.Stdout, graph.steps, github)
+	err := runHeadless(ctx, graph, plain, stdin)
+	if werr := writeTimingSummary(os.Stderr, graph, plain.times, time.Now()); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// installReporter receives the progress of a headless install.
+type installReporter interface {
+	stepStarted(i int)
+	stepOutput(i int, stream logStream, text string)
+	// stepFinished is called once per step with its final status and, for
+	// failed or skipped steps, the error that caused it (nil when a step was
+	// skipped because a need did not complete).
+	stepFinished(i int, status stepStatus, err error)
+}
+
+// runHeadless runs the graph without a terminal UI. Failures are handled as
+// installGraph.fail decides for a run without prompts: optional steps are
+// skipped and any other failure stops the run. Steps still running when the
+// run stops are cancelled and reported as interrupted once they return, with
+// whatever output they printed.
+//
+// Interactive steps run on a pseudo-terminal fed from stdin when it is a
+// terminal. Without one they get no input, and their failures say so.
+func runHeadless(ctx context.Context, graph *installGraph, report installReporter, stdin *stdinTerminal) error {
+	type event struct {
+		index  int
+		stream logStream
+		text   string
+		done   bool
+		err    error
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Events are read until every started step has returned, so sending
+	// never blocks for good.
+	events := make(chan event)
+	running := 0
+
+	start := func(i int) {
+		report.stepStarted(i)
+		running++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			step := graph.steps[i]
+			stepCtx := runCtx
+			if step.interactive && stdin != nil {
+				term, detach := stdin.step()
+				defer detach()
+				stepCtx = withTerminal(runCtx, term)
+			}
+			err := step.run(stepCtx, func(stream logStream, text string) {
+				events <- event{index: i, stream: stream, text: text}
+			})
+			if err != nil && step.interactive && stdin == nil && runCtx.Err() == nil {
+				err = fmt.Errorf("%w (the step needs a terminal to answer its prompts, and stdin is not one)", err)
+			}
+			events <- event{index: i, done: true, err: err}
+		}()
+	}
+	skip := func(i int, need string) {
+		report.stepStarted(i)
+		report.stepOutput(i, streamStatus, skipNotice(need))
+		report.stepFinished(i, stepSkipped, nil)
+	}
+	stop := func(err error) error {
+		cancel()
+		for running > 0 {
+			ev := <-events
+			if !ev.done {
+				report.stepOutput(ev.index, ev.stream, ev.text)
+				continue
+			}
+			running--
+			if ev.err == nil {
+				graph.status[ev.index] = stepSucceeded
+				report.stepFinished(ev.index, stepSucceeded, nil)
+				continue
+			}
+			// The step stays running in the graph, which the timing
+			// summary shows as interrupted.
+			report.stepFinished(ev.index, stepFailed, fmt.Errorf("interrupted: %w", ev.err))
+		}
+		return err
+	}
+
+	if err := graph.schedule(start, skip); err != nil {
+		return stop(err)
+	}
+	for !graph.done() {
+		select {
+		case <-ctx.Done():
+			return stop(ErrInstallCancelled)
+		case ev := <-events:
+			if !ev.done {
+				report.stepOutput(ev.index, ev.stream, ev.text)
+				continue
+			}
+			running--
+
+			if ev.err == nil {
+				graph.status[ev.index] = stepSucceeded
+				report.stepFinished(ev.index, stepSucceeded, nil)
+			} else if graph.fail(ev.index, false, ctx.Err() != nil) == failureSkip {
+				if hint := graph.steps[ev.index].hint; hint != "" {
+					report.stepOutput(ev.index, streamStatus, hint+"\n")
+				}
+				report.stepFinished(ev.index, stepSkipped, ev.err)
+			} else {
+				report.stepFinished(ev.index, stepFailed, ev.err)
+				if ctx.Err() != nil {
+					return stop(ErrInstallCancelled)
+				}
+				return stop(ev.err)
+			}
+
+			if err := graph.schedule(start, skip); err != nil {
+				return stop(err)
+			}
+		}
+	}
+	return nil
+}
+
+// plainReporter writes the install as plain lines for CI logs and other
+// non-terminal output. Each output line is prefixed with its step ID. On
+// GitHub Actions each step's output is instead folded into a ::group:: once
+// the step finishes or is interrupted, since groups cannot interleave.
+type plainReporter struct {
+	w       io.Writer
+	steps   []installStep
+	github  bool
+	started int
+	times   *stepTimes
+	output  []strings.Builder
+}
+
+func newPlainReporter(w io.Writer, steps []installStep, github bool) *plainReporter {
+	return &plainReporter{
+		w:      w,
+		steps:  steps,
+		github: github,
+		times:  newStepTimes(len(steps)),
+		output: make([]strings.Builder, len(steps)),
+	}
+}
+
+func (r *plainReporter) stepStarted(i int) {
+	r.started++
+	r.times.start(i, time.Now())
+	fmt.Fprintf(r.w, "==> [%d/%d] %s\n", r.started, len(r.steps), r.steps[i].title)
+}
+
+func (r *plainReporter) stepOutput(i int, _ logStream, text string) {
+	if r.github {
+		r.output[i].WriteString(text)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintf(r.w, "[%s] %s\n", r.steps[i].id, line)
+	}
+}
+
+func (r *plainReporter) stepFinished(i int, status stepStatus, err error) {
+	title := r.steps[i].title
+	if r.github && r.output[i].Len() > 0 {
+		fmt.Fprintf(r.w, "::group::%s\n%s", title, r.output[i].String())
+		if !strings.HasSuffix(r.output[i].String(), "\n") {
+			fmt.Fprintln(r.w)
+		}
+		fmt.Fprintln(r.w, "::endgroup::")
+	}
+
+	r.times.finish(i, time.Now())
+	elapsed, _ := r.times.elapsed(i, time.Now())
+	switch status {
+	case stepSucceeded:
+		fmt.Fprintf(r.w, "✓ %s (%s)\n", title, formatElapsed(elapsed))
+	case stepSkipped:
+		if err != nil {
+			fmt.Fprintf(r.w, "⚠ %s skipped: %v\n", title, err)
+		} else {
+			fmt.Fprintf(r.w, "⚠ %s skipped\n", title)
+		}
+		if r.github && err != nil {
+			fmt.Fprintf(r.w, "::warning title=%s::%v\n", title, err)
+		}
+	default:
+		fmt.Fprintf(r.w, "✗ %s failed: %v\n", title, err)
+		if r.github {
+			fmt.Fprintf(r.w, "::error title=%s::%v\n", title, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func plainSteps(shadcnErr error) []installStep {
+	return []installStep{
+		{
+			id:    "framework",
+			title: "Create Next.js project",
+			run: func(_ context.Context, write outputFunc) error {
+				write(streamStatus, "$ pnpm dlx create-next-app@latest demo\n")
+				return nil
+			},
+		},
+		{
+			id:       "shadcn-init",
+			title:    "Initialize shadcn",
+			needs:    []string{"framework"},
+			optional: true,
+			hint:     "rerun shadcn init",
+			run: func(_ context.Context, write outputFunc) error {
+				write(streamStderr, "fetching registry\n")
+				return shadcnErr
+			},
+		},
+		{
+			id:       "shadcn-add",
+			title:    "Install shadcn components",
+			needs:    []string{"shadcn-init"},
+			optional: true,
+			run:      func(context.Context, outputFunc) error { return nil },
+		},
+	}
+}
+
+func runPlainSteps(t *testing.T, steps []installStep, github bool) (string, error) {
+	t.Helper()
+	graph, err := newInstallGraph(steps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err = runHeadless(context.Background(), graph, newPlainReporter(&out, steps, github), nil)
+	return out.String(), err
+}
+
+func TestPlainOutputPrefixesLines(t *testing.T) {
+	out, err := runPlainSteps(t, plainSteps(nil), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		"==> [1/3] Create Next.js project\n",
+		"[framework] $ pnpm dlx create-next-app@latest demo\n",
+		"==> [2/3] Initialize shadcn\n",
+		"[shadcn-init] fetching registry\n",
+		"✓ Install shadcn components (",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "::group::") {
+		t.Fatalf("unexpected GitHub Actions commands:\n%s", out)
+	}
+}
+
+func TestPlainOutputGitHubGroupsAndSoftFailure(t *testing.T) {
+	out, err := runPlainSteps(t, plainSteps(errors.New("registry unreachable")), true)
+	if err != nil {
+		t.Fatalf("soft failures must not fail the run: %v", err)
+	}
+
+	for _, want := range []string{
+		"::group::Initialize shadcn\nfetching registry\nrerun shadcn init\n::endgroup::\n",
+		"⚠ Initialize shadcn skipped: registry unreachable\n",
+		"::warning title=Initialize shadcn::registry unreachable\n",
+		"⚠ Install shadcn components skipped\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestPlainOutputStopsOnHardFailure(t *testing.T) {
+	failure := errors.New("create-next-app exited 1")
+	steps := plainSteps(nil)
+	steps[0].run = func(context.Context, outputFunc) error { return failure }
+
+	out, err := runPlainSteps(t, steps, false)
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the step error, got %v", err)
+	}
+	if !strings.Contains(out, "✗ Create Next.js project failed: create-next-app exited 1\n") {
+		t.Fatalf("expected a failure line:\n%s", out)
+	}
+	if strings.Contains(out, "Initialize shadcn") {
+		t.Fatalf("dependent steps must not start:\n%s", out)
+	}
+}
+
+func TestPlainOutputGitHubKeepsInterruptedStepOutput(t *testing.T) {
+	failure := errors.New("exit status 1")
+	printed := make(chan struct{})
+	steps := []installStep{
+		{
+			id:    "dependencies",
+			title: "Install dependencies",
+			run: func(ctx context.Context, write outputFunc) error {
+				write(streamStdout, "Progress: resolved 10\n")
+				close(printed)
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+		{
+			id:    "versions",
+			title: "Record versions",
+			run: func(context.Context, outputFunc) error {
+				<-printed
+				return failure
+			},
+		},
+	}
+
+	out, err := runPlainSteps(t, steps, true)
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected the step error, got %v", err)
+	}
+	for _, want := range []string{
+		"::group::Install dependencies\nProgress: resolved 10\n::endgroup::\n",
+		"✗ Install dependencies failed: interrupted: context canceled\n",
+		"::error title=Install dependencies::interrupted: context canceled\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestPlainOutputGitHubKeepsCancelledStepOutput(t *testing.T) {
+	printed := make(chan struct{})
+	steps := []installStep{{
+		id:    "dependencies",
+		title: "Install dependencies",
+		run: func(ctx context.Context, write outputFunc) error {
+			write(streamStdout, "Progress: resolved 10\n")
+			close(printed)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}}
+	graph, err := newInstallGraph(steps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-printed
+		cancel()
+	}()
+	var out bytes.Buffer
+	if err := runHeadless(ctx, graph, newPlainReporter(&out, steps, true), nil); !errors.Is(err, ErrInstallCancelled) {
+		t.Fatalf("expected ErrInstallCancelled, got %v", err)
+	}
+	if want := "::group::Install dependencies\nProgress: resolved 10\n::endgroup::\n"; !strings.Contains(out.String(), want) {
+		t.Fatalf("expected %q in output:\n%s", want, out.String())
+	}
+}
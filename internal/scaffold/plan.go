@@ -0,0 +1,298 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// Plan is the declarative list of steps a Config expands into. It is what
+// the installer executes and what --dry-run prints.
+type Plan struct {
+	Root        string         `json:"root"`
+	ProjectPath string         `json:"projectPath"`
+	Config      options.Config `json:"config"`
+	Steps       []Step         `json:"steps"`
+}
+
+// Step is a single command in a Plan.
+type Step struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Dir is the absolute working directory for Command.
+	Dir string `json:"dir"`
+	// Needs lists step IDs that must have succeeded before this step runs.
+	Needs []string `json:"needs,omitempty"`
+	// SoftFail steps log Hint and let the run continue when they fail.
+	SoftFail bool   `json:"softFail"`
+	Hint     string `json:"hint,omitempty"`
+	// Creates is a path the step is expected to produce.
+	Creates string `json:"creates,omitempty"`
+	// Builtin names an in-process implementation of Command (see builtin.go).
+	Builtin string `json:"builtin,omitempty"`
+	// Weight is the step's expected share of the install time relative to
+	// the other steps; zero counts as 1.
+	Weight float64 `json:"weight,omitempty"`
+	// Interactive steps may prompt. They run on a pseudo-terminal that gets
+	// the keys typed into the install view, or in headless runs the lines
+	// typed on stdin when it is a terminal.
+	Interactive bool `json:"interactive,omitempty"`
+}
+
+// Step IDs used by BuildPlan.
+const (
+	stepDirectory    = "directory"
+	stepFramework    = "framework"
+	stepPackageName  = "package-name"
+	stepDependencies = "dependencies"
+	stepShadcnInit   = "shadcn-init"
+	stepShadcnAdd    = "shadcn-add"
+	stepVersions     = "versions"
+)
+
+// BuildPlan expands cfg into the steps needed to scaffold it from root.
+func BuildPlan(cfg options.Config, root string) (Plan, error) {
+	if cfg.ProjectName == "" {
+		return Plan{}, errors.New("project name is required")
+	}
+
+	// The resolved versions go into the plan's config, so the checkpoint
+	// keeps them and a resume installs the same ones.
+	versions := resolveVersions(cfg)
+	cfg.Versions = versions
+
+	targetDir := cfg.TargetDir()
+	projectPath := filepath.Join(root, targetDir)
+	plan := Plan{
+		Root:        root,
+		ProjectPath: projectPath,
+		Config:      cfg,
+	}
+
+	framework := frameworkStep(cfg, targetDir, versions)
+	framework.Dir = root
+	framework.Creates = projectPath
+	framework.Weight = weightFramework
+
+	if parent := filepath.Dir(targetDir); parent != "." {
+		plan.Steps = append(plan.Steps, Step{
+			ID:      stepDirectory,
+			Title:   fmt.Sprintf("Create directory %s", filepath.ToSlash(parent)),
+			Command: "mkdir",
+			Args:    []string{"-p", filepath.ToSlash(parent)},
+			Dir:     root,
+			Creates: filepath.Join(root, parent),
+			Builtin: builtinMkdir,
+		})
+		framework.Needs = []string{stepDirectory}
+	}
+	plan.Steps = append(plan.Steps, framework)
+
+	// The scaffolders name the package after the directory; rename it when
+	// the two differ, e.g. apps/web published as @acme/web.
+	packageReady := stepFramework
+	pm := newPackageManager(cfg.PackageManager)
+	if cfg.ProjectName != filepath.Base(targetDir) {
+		rename := Step{
+			ID:      stepPackageName,
+			Title:   fmt.Sprintf("Set package name to %s", cfg.ProjectName),
+			Dir:     projectPath,
+			Needs:   []string{stepFramework},
+			Builtin: builtinPkgSet,
+		}
+		rename.Command, rename.Args = pm.pkgSet("name=" + cfg.ProjectName)
+		plan.Steps = append(plan.Steps, rename)
+		packageReady = stepPackageName
+	}
+
+	if deps := collectDependencies(cfg); len(deps) > 0 {
+		specs := make([]string, len(deps))
+		for i, dep := range deps {
+			specs[i] = packageSpec(versions, dep)
+		}
+		name, args := pm.add(specs...)
+		plan.Steps = append(plan.Steps, Step{
+			ID:      stepDependencies,
+			Title:   "Install selected dependencies",
+			Command: name,
+			Args:    args,
+			Dir:     projectPath,
+			Needs:   []string{packageReady},
+			Weight:  weightDependencies,
+		})
+	}
+
+	plan.Steps = append(plan.Steps, shadcnSteps(projectPath, cfg, versions)...)
+
+	// Only needs the project directory, so it runs alongside the install.
+	plan.Steps = append(plan.Steps, versionsStep(projectPath, versions))
+
+	return plan, nil
+}
+
+// frameworkStep runs the framework scaffolder against targetDir, a path
+// relative to the plan root.
+func frameworkStep(cfg options.Config, targetDir string, versions map[string]string) Step {
+	step := Step{
+		ID:    stepFramework,
+		Title: fmt.Sprintf("Create %s project", describeFramework(cfg.Framework)),
+	}
+
+	pm := newPackageManager(cfg.PackageManager)
+	switch cfg.Framework {
+	case options.FrameworkTanstackStart:
+		step.Command, step.Args = pm.create(initializerSpec(packageSpec(versions, tanstackScaffolder)), filepath.ToSlash(targetDir))
+		// The TanStack scaffolder asks about add-ons and has no flag to
+		// accept its defaults.
+		step.Interactive = true
+	default:
+		step.Command, step.Args = pm.dlx(
+			packageSpec(versions, nextScaffolder),
+			"--yes",
+			filepath.ToSlash(targetDir),
+			"--app",
+			"--ts",
+			"--tailwind",
+			"--eslint",
+			"--turbopack",
+			"--src-dir",
+			pm.createNextAppFlag(),
+			"--import-alias",
+			"@/*",
+		)
+	}
+
+	return step
+}
+
+func shadcnSteps(projectPath string, cfg options.Config, versions map[string]string) []Step {
+	if !hasShadcnSteps(cfg) {
+		return nil
+	}
+
+	color := defaultColor(cfg.ShadcnColor)
+	needs := []string{stepFramework}
+	if cfg.ProjectName != filepath.Base(cfg.TargetDir()) {
+		needs = append(needs, stepPackageName)
+	}
+	if len(collectDependencies(cfg)) > 0 {
+		needs = append(needs, stepDependencies)
+	}
+
+	pm := newPackageManager(cfg.PackageManager)
+	shadcn := packageSpec(versions, shadcnCLI)
+
+	initStep := Step{
+		ID:       stepShadcnInit,
+		Title:    fmt.Sprintf("Initialize shadcn (%s)", color),
+		Dir:      projectPath,
+		Needs:    needs,
+		SoftFail: true,
+		Hint:     "⚠️ shadcn init failed. You can rerun: " + commandLine(pm.dlx(shadcn, "init")),
+		Weight:   weightShadcnInit,
+		// -y accepts the defaults, but shadcn still asks how to resolve peer
+		// dependency conflicts, e.g. on React 19.
+		Interactive: true,
+	}
+	initStep.Command, initStep.Args = pm.dlx(shadcn, "init", "-y", "--base-color", color)
+
+	addStep := Step{
+		ID:       stepShadcnAdd,
+		Title:    "Install shadcn components",
+		Dir:      projectPath,
+		Needs:    []string{stepShadcnInit},
+		SoftFail: true,
+		Hint:     "⚠️ shadcn component install failed. You can rerun: " + commandLine(pm.dlx(shadcn, "add", "--all")),
+		Weight:   weightShadcnAdd,
+		// Same peer dependency question as init.
+		Interactive: true,
+	}
+	addStep.Command, addStep.Args = pm.dlx(shadcn, "add", "--all", "-y")
+
+	return []Step{initStep, addStep}
+}
+
+func hasShadcnSteps(cfg options.Config) bool {
+	return !cfg.SkipShadcnOps && hasTool(cfg.Tooling, options.ToolShadcn)
+}
+
+// CommandLine renders the step's command as a shell-quoted string.
+func (s Step) CommandLine() string {
+	parts := make([]string, 0, len(s.Args)+1)
+	parts = append(parts, shellQuote(s.Command))
+	for _, arg := range s.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// WriteText prints the plan as a numbered, human-readable list.
+func (p Plan) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan for %s (%d steps), run from %s\n", p.Config.ProjectName, len(p.Steps), p.Root)
+
+	titles := make(map[string]string, len(p.Steps))
+	for i, step := range p.Steps {
+		titles[step.ID] = step.Title
+		fmt.Fprintf(&b, "\n%d. %s\n", i+1, step.Title)
+		fmt.Fprintf(&b, "   dir: %s\n", p.relative(step.Dir))
+		fmt.Fprintf(&b, "   run: %s\n", step.CommandLine())
+		if len(step.Needs) > 0 {
+			needs := make([]string, len(step.Needs))
+			for j, id := range step.Needs {
+				needs[j] = titles[id]
+			}
+			fmt.Fprintf(&b, "   after: %s\n", strings.Join(needs, ", "))
+		}
+		if step.SoftFail {
+			b.WriteString("   on failure: warn and continue\n")
+		}
+		if step.Interactive {
+			b.WriteString("   may prompt for input\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON prints the plan as indented JSON.
+func (p Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+func (p Plan) relative(dir string) string {
+	rel, err := filepath.Rel(p.Root, dir)
+	if err != nil {
+		return dir
+	}
+	return rel
+}
+
+// shellQuote wraps value in single quotes when it contains characters a
+// POSIX shell would interpret.
+func shellQuote(value string) string {
+	if value == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./@:=,+%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
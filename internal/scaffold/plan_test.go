@@ -0,0 +1,222 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+	"github.com/mikekenway/create-ekko-app/internal/testutil/golden"
+)
+
+func TestBuildPlanNextWithShadcn(t *testing.T) {
+	cfg := options.Config{
+		ProjectName: "demo",
+		Framework:   options.FrameworkNext,
+		Auth:        options.AuthClerk,
+		Tooling:     []options.ToolingOption{options.ToolShadcn},
+		ShadcnColor: "slate",
+	}
+
+	plan, err := BuildPlan(cfg, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids := make([]string, len(plan.Steps))
+	for i, step := range plan.Steps {
+		ids[i] = step.ID
+	}
+	wantIDs := []string{stepFramework, stepDependencies, stepShadcnInit, stepShadcnAdd, stepVersions}
+	if !slices.Equal(ids, wantIDs) {
+		t.Fatalf("unexpected step ids: %v", ids)
+	}
+
+	framework := plan.Steps[0]
+	if framework.Dir != "/work" || framework.Creates != "/work/demo" {
+		t.Fatalf("unexpected framework step: %+v", framework)
+	}
+
+	deps := plan.Steps[1]
+	if deps.Dir != "/work/demo" || deps.Args[0] != "add" || !slices.Contains(deps.Args, "@clerk/nextjs@"+DefaultVersions()["@clerk/nextjs"]) {
+		t.Fatalf("unexpected dependency step: %+v", deps)
+	}
+
+	init := plan.Steps[2]
+	if !init.SoftFail || !slices.Contains(init.Args, "slate") {
+		t.Fatalf("unexpected shadcn init step: %+v", init)
+	}
+	if !slices.Equal(plan.Steps[3].Needs, []string{stepShadcnInit}) {
+		t.Fatalf("expected shadcn add to need init, got %v", plan.Steps[3].Needs)
+	}
+}
+
+func TestBuildPlanSkipsShadcnAndEmptyDeps(t *testing.T) {
+	cfg := options.Config{
+		ProjectName:   "demo",
+		Framework:     options.FrameworkTanstackStart,
+		Auth:          options.AuthNone,
+		Database:      options.DatabaseNone,
+		Tooling:       []options.ToolingOption{options.ToolShadcn},
+		SkipShadcnOps: true,
+	}
+
+	plan, err := BuildPlan(cfg, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Steps) != 3 {
+		t.Fatalf("expected framework, dependency and versions steps, got %d", len(plan.Steps))
+	}
+	if got := plan.Steps[0].Args; !slices.Equal(got, []string{"create", "@tanstack/start@" + DefaultVersions()[tanstackScaffolder], "demo"}) {
+		t.Fatalf("unexpected tanstack args: %v", got)
+	}
+
+	cfg.Tooling = nil
+	plan, err = BuildPlan(cfg, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Steps) != 2 {
+		t.Fatalf("expected only the framework and versions steps, got %d", len(plan.Steps))
+	}
+}
+
+func TestPlanWriters(t *testing.T) {
+	plan, err := BuildPlan(options.Config{ProjectName: "demo", Framework: options.FrameworkNext}, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var text bytes.Buffer
+	if err := plan.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "--import-alias '@/*'") {
+		t.Fatalf("expected quoted import alias in:\n%s", text.String())
+	}
+
+	var raw bytes.Buffer
+	if err := plan.WriteJSON(&raw); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Plan
+	if err := json.Unmarshal(raw.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if decoded.Steps[0].Command != "pnpm" {
+		t.Fatalf("unexpected decoded plan: %+v", decoded)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	cases := map[string]string{
+		"create-next-app@latest": "create-next-app@latest",
+		"@/*":                    "'@/*'",
+		"it's":                   `'it'\''s'`,
+		"":                       "''",
+	}
+	for in, want := range cases {
+		if got := shellQuote(in); got != want {
+			t.Fatalf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildPlanWithDirectory(t *testing.T) {
+	cfg := options.Config{
+		ProjectName: "@acme/web",
+		Directory:   "apps/web",
+		Framework:   options.FrameworkNext,
+		Tooling:     []options.ToolingOption{options.ToolResend},
+	}
+
+	plan, err := BuildPlan(cfg, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.ProjectPath != "/work/apps/web" {
+		t.Fatalf("unexpected project path %s", plan.ProjectPath)
+	}
+
+	ids := make([]string, len(plan.Steps))
+	for i, step := range plan.Steps {
+		ids[i] = step.ID
+	}
+	want := []string{stepDirectory, stepFramework, stepPackageName, stepDependencies, stepVersions}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("unexpected step ids: %v", ids)
+	}
+
+	if plan.Steps[0].Creates != "/work/apps" {
+		t.Fatalf("unexpected mkdir step: %+v", plan.Steps[0])
+	}
+	if !slices.Contains(plan.Steps[1].Args, "apps/web") {
+		t.Fatalf("expected scaffolder to receive the relative path: %v", plan.Steps[1].Args)
+	}
+	if got := pkgSetFields(plan.Steps[2].Args); !slices.Equal(got, []string{"name=@acme/web"}) {
+		t.Fatalf("unexpected rename args: %v", got)
+	}
+	if !slices.Equal(plan.Steps[3].Needs, []string{stepPackageName}) {
+		t.Fatalf("expected install to wait for the rename, got %v", plan.Steps[3].Needs)
+	}
+}
+
+func TestBuildPlanScopedNameDefaultsDirectory(t *testing.T) {
+	plan, err := BuildPlan(options.Config{ProjectName: "@acme/web", Framework: options.FrameworkTanstackStart}, "/work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.ProjectPath != "/work/web" {
+		t.Fatalf("unexpected project path %s", plan.ProjectPath)
+	}
+	if len(plan.Steps) != 3 || plan.Steps[1].ID != stepPackageName {
+		t.Fatalf("expected framework and rename steps, got %+v", plan.Steps)
+	}
+}
+
+// TestBuildPlanGolden compares the plan for every framework, auth, database
+// and tooling combination with testdata/plans. Each file holds one
+// framework, auth and database choice with every tooling subset; run
+// `go test ./internal/scaffold -run Golden -update` after an intended change
+// and review the diff.
+func TestBuildPlanGolden(t *testing.T) {
+	const root = "/work"
+
+	files := map[string]*bytes.Buffer{}
+	var names []string
+	for _, cfg := range everyConfig() {
+		name := fmt.Sprintf("%s-%s-%s.golden", cfg.Framework, cfg.Auth, cfg.Database)
+		if files[name] == nil {
+			files[name] = &bytes.Buffer{}
+			names = append(names, name)
+		}
+
+		plan, err := BuildPlan(cfg, filepath.FromSlash(root))
+		if err != nil {
+			t.Fatalf("%s: %v", configName(cfg), err)
+		}
+		var text bytes.Buffer
+		if err := plan.WriteText(&text); err != nil {
+			t.Fatal(err)
+		}
+
+		b := files[name]
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "=== tooling: %s\n", path.Base(configName(cfg)))
+		b.WriteString(strings.ReplaceAll(text.String(), filepath.FromSlash(root), "<root>"))
+	}
+
+	for _, name := range names {
+		t.Run(strings.TrimSuffix(name, ".golden"), func(t *testing.T) {
+			golden.Check(t, filepath.Join("plans", name), files[name].String())
+		})
+	}
+}
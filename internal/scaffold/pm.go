@@ -0,0 +1,92 @@
+package scaffold
+
+import (
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// packageManager translates package-manager-neutral operations into the
+// command line for a specific tool.
+type packageManager options.PackageManager
+
+func newPackageManager(pm options.PackageManager) packageManager {
+	if pm == "" {
+		return packageManager(options.PackageManagerPnpm)
+	}
+	return packageManager(pm)
+}
+
+// dlx runs a package binary without installing it (pnpm dlx, npx, ...).
+func (pm packageManager) dlx(pkg string, args ...string) (string, []string) {
+	runner := options.PackageManager(pm).Runner()
+	switch options.PackageManager(pm) {
+	case options.PackageManagerNpm:
+		return runner, append([]string{"--yes", pkg}, args...)
+	case options.PackageManagerBun:
+		return runner, append([]string{pkg}, args...)
+	default:
+		return runner, append([]string{"dlx", pkg}, args...)
+	}
+}
+
+// create runs a create-* initializer (pnpm create, npm create, ...).
+func (pm packageManager) create(initializer string, args ...string) (string, []string) {
+	return string(pm), append([]string{"create", initializer}, args...)
+}
+
+// add installs dependencies into the current project, saving the exact
+// versions it resolved rather than a ^ range.
+func (pm packageManager) add(deps ...string) (string, []string) {
+	var args []string
+	switch options.PackageManager(pm) {
+	case options.PackageManagerNpm:
+		args = []string{"install", "--save-exact"}
+	case options.PackageManagerYarn, options.PackageManagerBun:
+		args = []string{"add", "--exact"}
+	default:
+		args = []string{"add", "--save-exact"}
+	}
+	return string(pm), append(args, deps...)
+}
+
+// pkgSetScript is the Node equivalent of `npm pkg set` for package managers
+// without one; it sets each key=value argument as a top-level field.
+const pkgSetScript = `const fs = require("fs");
+const pkg = JSON.parse(fs.readFileSync("package.json", "utf8"));
+for (const arg of process.argv.slice(1)) {
+  const i = arg.indexOf("=");
+  pkg[arg.slice(0, i)] = arg.slice(i + 1);
+}
+fs.writeFileSync("package.json", JSON.stringify(pkg, null, 2) + "\n");`
+
+// pkgSet sets top-level package.json fields, each given as key=value. pnpm
+// and Yarn have no command for it, but both run on Node.
+func (pm packageManager) pkgSet(fields ...string) (string, []string) {
+	switch options.PackageManager(pm) {
+	case options.PackageManagerNpm:
+		return "npm", append([]string{"pkg", "set"}, fields...)
+	case options.PackageManagerBun:
+		return "bun", append([]string{"pm", "pkg", "set"}, fields...)
+	default:
+		return "node", append([]string{"-e", pkgSetScript}, fields...)
+	}
+}
+
+// run invokes a package.json script.
+func (pm packageManager) run(script string) string {
+	switch options.PackageManager(pm) {
+	case options.PackageManagerNpm, options.PackageManagerBun:
+		return string(pm) + " run " + script
+	default:
+		return string(pm) + " " + script
+	}
+}
+
+// createNextAppFlag selects the package manager create-next-app installs with.
+func (pm packageManager) createNextAppFlag() string {
+	return "--use-" + string(pm)
+}
+
+// commandLine renders a command for hints and messages.
+func commandLine(name string, args []string) string {
+	return Step{Command: name, Args: args}.CommandLine()
+}
@@ -0,0 +1,115 @@
+package scaffold
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+func TestPlanPerPackageManager(t *testing.T) {
+	cases := []struct {
+		pm         options.PackageManager
+		nextCmd    string
+		nextPrefix []string
+		nextFlag   string
+		startCmd   string
+		addCmd     string
+		addPrefix  []string
+		dev        string
+	}{
+		{options.PackageManagerPnpm, "pnpm", []string{"dlx", pinned(nextScaffolder)}, "--use-pnpm", "pnpm", "pnpm", []string{"add", "--save-exact"}, "pnpm dev"},
+		{options.PackageManagerNpm, "npx", []string{"--yes", pinned(nextScaffolder)}, "--use-npm", "npm", "npm", []string{"install", "--save-exact"}, "npm run dev"},
+		{options.PackageManagerYarn, "yarn", []string{"dlx", pinned(nextScaffolder)}, "--use-yarn", "yarn", "yarn", []string{"add", "--exact"}, "yarn dev"},
+		{options.PackageManagerBun, "bunx", []string{pinned(nextScaffolder)}, "--use-bun", "bun", "bun", []string{"add", "--exact"}, "bun run dev"},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.pm), func(t *testing.T) {
+			cfg := options.Config{
+				ProjectName:    "demo",
+				Framework:      options.FrameworkNext,
+				Tooling:        []options.ToolingOption{options.ToolResend},
+				PackageManager: tc.pm,
+			}
+			plan, err := BuildPlan(cfg, "/work")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next := plan.Steps[0]
+			if next.Command != tc.nextCmd || !slices.Equal(next.Args[:len(tc.nextPrefix)], tc.nextPrefix) {
+				t.Fatalf("unexpected next command: %s", next.CommandLine())
+			}
+			if !slices.Contains(next.Args, tc.nextFlag) {
+				t.Fatalf("expected %s in %s", tc.nextFlag, next.CommandLine())
+			}
+
+			add := plan.Steps[1]
+			if add.Command != tc.addCmd || !slices.Equal(add.Args, append(tc.addPrefix, pinned("resend"))) {
+				t.Fatalf("unexpected add command: %s", add.CommandLine())
+			}
+
+			cfg.Framework = options.FrameworkTanstackStart
+			plan, err = BuildPlan(cfg, "/work")
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := plan.Steps[0]
+			if start.Command != tc.startCmd || !slices.Equal(start.Args, []string{"create", "@tanstack/start@" + DefaultVersions()[tanstackScaffolder], "demo"}) {
+				t.Fatalf("unexpected start command: %s", start.CommandLine())
+			}
+
+			if got := newPackageManager(tc.pm).run("dev"); got != tc.dev {
+				t.Fatalf("expected %q, got %q", tc.dev, got)
+			}
+		})
+	}
+}
+
+func TestPackageNamePerPackageManager(t *testing.T) {
+	cases := map[options.PackageManager]string{
+		options.PackageManagerNpm:  "npm pkg set name=@acme/web",
+		options.PackageManagerBun:  "bun pm pkg set name=@acme/web",
+		options.PackageManagerPnpm: "node -e ",
+		options.PackageManagerYarn: "node -e ",
+	}
+	for pm, want := range cases {
+		t.Run(string(pm), func(t *testing.T) {
+			cfg := options.Config{
+				ProjectName:    "@acme/web",
+				Framework:      options.FrameworkNext,
+				PackageManager: pm,
+			}
+			plan, err := BuildPlan(cfg, "/work")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rename := plan.Steps[1]
+			if rename.ID != stepPackageName || !strings.HasPrefix(rename.CommandLine(), want) {
+				t.Fatalf("unexpected rename command: %s", rename.CommandLine())
+			}
+			if got := pkgSetFields(rename.Args); !slices.Equal(got, []string{"name=@acme/web"}) {
+				t.Fatalf("the builtin would set %q", got)
+			}
+		})
+	}
+}
+
+func TestShadcnHintsUsePackageManager(t *testing.T) {
+	cfg := options.Config{
+		ProjectName:    "demo",
+		Framework:      options.FrameworkNext,
+		Tooling:        []options.ToolingOption{options.ToolShadcn},
+		PackageManager: options.PackageManagerBun,
+	}
+	plan, err := BuildPlan(cfg, "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	init := plan.Steps[2]
+	if init.Command != "bunx" || init.Hint != "⚠️ shadcn init failed. You can rerun: bunx "+pinned(shadcnCLI)+" init" {
+		t.Fatalf("unexpected shadcn init step: %+v", init)
+	}
+}
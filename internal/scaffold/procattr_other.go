@@ -0,0 +1,9 @@
+//go:build !unix
+
+package scaffold
+
+import "os/exec"
+
+func killProcessGroup(cmd *exec.Cmd) {}
+
+func attachTerminal(cmd *exec.Cmd) {}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package scaffold
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts cmd in its own process group and makes
+// cancellation kill the whole group. Package managers spawn node processes of
+// their own, and those must not keep writing into a directory that is about
+// to be rolled back.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// attachTerminal starts cmd in a session of its own with the pseudo-terminal
+// on its stdin as the controlling terminal, so prompts can read from it.
+// The session leader also leads the process group, which cancellation kills
+// as in killProcessGroup.
+func attachTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,111 @@
+package scaffold
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expected share of the install time for the long-running steps. Steps
+// without a weight count as 1.
+const (
+	weightFramework    = 40
+	weightDependencies = 30
+	weightShadcnInit   = 8
+	weightShadcnAdd    = 12
+)
+
+// pnpmProgress matches the lines pnpm prints while installing when its
+// output is not a terminal, e.g.
+// "Progress: resolved 312, reused 298, downloaded 14, added 120".
+var pnpmProgress = regexp.MustCompile(`Progress: resolved (\d+), reused (\d+), downloaded (\d+), added (\d+)(, done)?`)
+
+// progressPhase is a stretch of a step's output that starts with a line
+// containing marker and spans [start, end] of the step's progress. pnpm
+// progress lines move the step through the current phase.
+type progressPhase struct {
+	marker     string
+	start, end float64
+}
+
+// createNextAppPhases are the milestones create-next-app prints. The
+// dependency install, reported through pnpm's progress lines, is most of it.
+var createNextAppPhases = []progressPhase{
+	{marker: "Creating a new Next.js app", start: 0.02, end: 0.1},
+	{marker: "Installing dependencies", start: 0.1, end: 0.9},
+	{marker: "Initialized a git repository", start: 0.95, end: 0.95},
+	{marker: "Success! Created", start: 1, end: 1},
+}
+
+// progressPhases returns the milestones to look for in step's output.
+func progressPhases(step Step) []progressPhase {
+	for _, arg := range step.Args {
+		if strings.HasPrefix(arg, "create-next-app@") {
+			return createNextAppPhases
+		}
+	}
+	return nil
+}
+
+// progressEstimator derives a step's progress from its output. Steps whose
+// output it does not recognise report nothing, and the install view falls
+// back to counting output lines.
+type progressEstimator struct {
+	phases []progressPhase
+	phase  int
+	// from and to bound the current phase.
+	from, to float64
+	value    float64
+	// parsed is set once any line was recognised.
+	parsed bool
+}
+
+func newProgressEstimator(phases []progressPhase) *progressEstimator {
+	e := &progressEstimator{phases: phases, phase: -1, to: 1}
+	if len(phases) > 0 {
+		e.to = phases[0].start
+	}
+	return e
+}
+
+// observe feeds one line of output to the estimator and reports the step's
+// progress when the line moved it.
+func (e *progressEstimator) observe(line string) (float64, bool) {
+	for i := e.phase + 1; i < len(e.phases); i++ {
+		if strings.Contains(line, e.phases[i].marker) {
+			e.phase = i
+			e.from, e.to = e.phases[i].start, e.phases[i].end
+			return e.advance(e.from)
+		}
+	}
+
+	if m := pnpmProgress.FindStringSubmatch(line); m != nil {
+		resolved, _ := strconv.Atoi(m[1])
+		reused, _ := strconv.Atoi(m[2])
+		downloaded, _ := strconv.Atoi(m[3])
+		added, _ := strconv.Atoi(m[4])
+
+		// Packages are fetched (reused or downloaded) and then added, so
+		// count both halves against what has been resolved so far.
+		fraction := 1.0
+		if m[5] == "" {
+			fraction = 0
+			if resolved > 0 {
+				fraction = float64(reused+downloaded+added) / float64(2*resolved)
+			}
+		}
+		return e.advance(e.from + minFloat(fraction, 1)*(e.to-e.from))
+	}
+
+	return 0, false
+}
+
+// advance moves the estimate forward; progress never goes back.
+func (e *progressEstimator) advance(value float64) (float64, bool) {
+	e.parsed = true
+	if value <= e.value {
+		return e.value, false
+	}
+	e.value = value
+	return value, true
+}
@@ -0,0 +1,69 @@
+package scaffold
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProgressEstimatorPnpmLines(t *testing.T) {
+	e := newProgressEstimator(nil)
+
+	if _, ok := e.observe("Packages: +120"); ok || e.parsed {
+		t.Fatal("unrelated lines must not move progress")
+	}
+
+	value, ok := e.observe("Progress: resolved 100, reused 40, downloaded 10, added 0")
+	if !ok || math.Abs(value-0.25) > 1e-9 {
+		t.Fatalf("expected 0.25, got %v (%v)", value, ok)
+	}
+
+	// Resolution finding more packages must not move the bar backwards.
+	if value, ok := e.observe("Progress: resolved 200, reused 40, downloaded 10, added 0"); ok || value != 0.25 {
+		t.Fatalf("expected progress to hold at 0.25, got %v (%v)", value, ok)
+	}
+
+	if value, ok := e.observe("Progress: resolved 200, reused 180, downloaded 20, added 200, done"); !ok || value != 1 {
+		t.Fatalf("expected done to reach 1, got %v (%v)", value, ok)
+	}
+}
+
+func TestProgressEstimatorCreateNextAppPhases(t *testing.T) {
+	e := newProgressEstimator(progressPhases(Step{Args: []string{"dlx", "create-next-app@latest", "demo"}}))
+
+	steps := []struct {
+		line string
+		want float64
+	}{
+		{"Progress: resolved 1, reused 1, downloaded 0, added 1, done", 0.02},
+		{"Creating a new Next.js app in /work/demo.", 0.02},
+		{"Installing dependencies:", 0.1},
+		{"Progress: resolved 300, reused 150, downloaded 0, added 0", 0.3},
+		{"Progress: resolved 300, reused 300, downloaded 0, added 300, done", 0.9},
+		{"Initialized a git repository.", 0.95},
+		{"Success! Created demo at /work/demo", 1},
+	}
+	for _, step := range steps {
+		e.observe(step.line)
+		if math.Abs(e.value-step.want) > 1e-9 {
+			t.Fatalf("after %q: progress = %v, want %v", step.line, e.value, step.want)
+		}
+	}
+}
+
+func TestInstallModelWeightsProgress(t *testing.T) {
+	graph, err := newInstallGraph([]installStep{
+		{id: "framework", weight: weightFramework},
+		{id: "env"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newInstallModel(t.Context(), graph)
+	m.graph.status[1] = stepSucceeded
+	m.stepProgress[0] = 0.5
+
+	want := (weightFramework*0.5 + 1) / (weightFramework + 1)
+	if got := m.currentPercent(); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("percent = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// artifacts records the paths a run created, so a failed or cancelled run
+// can be rolled back without touching anything that existed beforehand.
+type artifacts struct {
+	mu    sync.Mutex
+	root  string
+	paths []string
+}
+
+func newArtifacts(root string) *artifacts {
+	return &artifacts{root: root}
+}
+
+// track is called before a step that declares Creates. The returned func is
+// called once the step has finished, successfully or not, and records the
+// top-most directory the step brought into existence.
+func (a *artifacts) track(path string) func() {
+	missing := firstMissing(a.root, path)
+	return func() {
+		if missing == "" {
+			return
+		}
+		if _, err := os.Lstat(missing); err != nil {
+			return
+		}
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if !slices.Contains(a.paths, missing) {
+			a.paths = append(a.paths, missing)
+		}
+	}
+}
+
+// Paths returns the recorded artifacts in creation order.
+func (a *artifacts) Paths() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.paths)
+}
+
+// rollback removes every recorded artifact, newest first.
+func (a *artifacts) rollback() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var errs []error
+	for i := len(a.paths) - 1; i >= 0; i-- {
+		if err := os.RemoveAll(a.paths[i]); err != nil {
+			errs = append(errs, fmt.Errorf("remove %s: %w", a.paths[i], err))
+		}
+	}
+	a.paths = nil
+	return errors.Join(errs...)
+}
+
+// firstMissing returns the top-most ancestor of path, below root, that does
+// not exist yet. It returns "" when path already exists or lies outside root.
+func firstMissing(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		if _, err := os.Lstat(current); errors.Is(err, os.ErrNotExist) {
+			return current
+		}
+	}
+	return ""
+}
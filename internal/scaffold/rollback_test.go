@@ -0,0 +1,80 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestArtifactsRollbackRemovesOnlyNewPaths(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "apps")
+	if err := os.Mkdir(existing, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	created := newArtifacts(root)
+
+	project := filepath.Join(existing, "web")
+	done := created.track(project)
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	done()
+
+	if got := created.Paths(); !slices.Equal(got, []string{project}) {
+		t.Fatalf("expected only the project dir to be tracked, got %v", got)
+	}
+
+	if err := created.rollback(); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	if _, err := os.Stat(project); !os.IsNotExist(err) {
+		t.Fatalf("expected project dir to be removed, got %v", err)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Fatalf("pre-existing parent must survive rollback: %v", err)
+	}
+}
+
+func TestArtifactsIgnorePreexistingProject(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "web")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	created := newArtifacts(root)
+	created.track(project)()
+
+	if got := created.Paths(); len(got) != 0 {
+		t.Fatalf("expected nothing to be tracked, got %v", got)
+	}
+}
+
+func TestArtifactsTrackTopMostNewDirectory(t *testing.T) {
+	root := t.TempDir()
+	created := newArtifacts(root)
+
+	project := filepath.Join(root, "apps", "web")
+	done := created.track(project)
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	done()
+
+	if got := created.Paths(); !slices.Equal(got, []string{filepath.Join(root, "apps")}) {
+		t.Fatalf("expected the new parent to be tracked, got %v", got)
+	}
+}
+
+func TestArtifactsSkipStepsThatCreatedNothing(t *testing.T) {
+	root := t.TempDir()
+	created := newArtifacts(root)
+	created.track(filepath.Join(root, "web"))()
+
+	if got := created.Paths(); len(got) != 0 {
+		t.Fatalf("expected nothing to be tracked, got %v", got)
+	}
+}
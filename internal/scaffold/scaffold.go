@@ -1,113 +1,309 @@
 package scaffold
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
-	"sync"
 
+	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/log"
 
+	"github.com/mikekenway/create-ekko-app/internal/doctor"
 	"github.com/mikekenway/create-ekko-app/internal/options"
 )
 
+// RunOptions tune how Run executes a plan.
+type RunOptions struct {
+	// Interactive allows Run to prompt, e.g. before rolling back a failed run.
+	Interactive bool
+	// CleanupOnFailure removes everything this run created when it fails or
+	// is cancelled, without asking.
+	CleanupOnFailure bool
+	// Plain streams the install as plain lines instead of the interactive
+	// view, for CI logs and other non-terminal output.
+	Plain bool
+	// Events, when set, receives the run as JSON Lines events instead of
+	// drawing any install output.
+	Events io.Writer
+	// LogFile overrides where the install log is written. It defaults to
+	// .ekko/install.log inside the project.
+	LogFile string
+}
+
 // Run executes the scaffolding workflow using the provided selections.
-func Run(ctx context.Context, cfg options.Config, logger *log.Logger) error {
-	if cfg.ProjectName == "" {
-		return errors.New("project name is required")
+func Run(ctx context.Context, cfg options.Config, opts RunOptions, logger *log.Logger) error {
+	if err := options.ValidateProjectName(cfg.ProjectName); err != nil {
+		return err
+	}
+	if cfg.Directory != "" {
+		if err := options.ValidateDirectory(cfg.Directory); err != nil {
+			return err
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("resolve working directory: %w", err)
 	}
+	runner := newRunner(ctx, logger, cwd)
 
-	runner, err := newRunner(ctx, logger)
+	plan, err := BuildPlan(cfg, runner.cwd)
 	if err != nil {
 		return err
 	}
 
-	steps, err := runner.buildSteps(cfg)
+	if err := checkProjectPath(plan.ProjectPath, cfg.Force); err != nil {
+		return err
+	}
+
+	return runner.execute(ctx, plan, nil, opts)
+}
+
+// checkProjectPath refuses to scaffold into an existing, non-empty directory
+// unless force is set and the scaffolders would accept its contents; they
+// would otherwise fail only once the framework step runs.
+func checkProjectPath(path string, force bool) error {
+	inUse, err := options.DirectoryInUse(path)
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", path, err)
+	}
+	if !inUse {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("%s already exists and is not empty; pick another name or pass --force to scaffold into it anyway", path)
+	}
+
+	conflicts, err := options.ScaffoldConflicts(path)
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", path, err)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s contains files the scaffolder would refuse to overwrite (%s); move them or pick another name", path, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// Resume continues an interrupted scaffold from the checkpoint stored in
+// dir/.ekko/state.json, starting at the first step that did not complete.
+func Resume(ctx context.Context, dir string, opts RunOptions, logger *log.Logger) error {
+	projectPath, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", dir, err)
+	}
+
+	state, err := loadCheckpoint(projectPath)
 	if err != nil {
 		return err
 	}
 
-	if len(steps) == 0 {
+	root := resumeRoot(projectPath, state.Config)
+	runner := newRunner(ctx, logger, root)
+
+	plan, err := BuildPlan(state.Config, root)
+	if err != nil {
+		return err
+	}
+	if plan.ProjectPath != projectPath {
+		return fmt.Errorf("checkpoint in %s belongs to %s", projectPath, plan.ProjectPath)
+	}
+
+	logger.Info("Resuming scaffold", "project", state.Config.ProjectName, "completed", len(state.Completed), "total", len(plan.Steps))
+	return runner.execute(ctx, plan, state.Completed, opts)
+}
+
+// execute runs every plan step not listed in completed, then finishes the
+// project or, on failure, offers a rollback.
+func (r *runner) execute(ctx context.Context, plan Plan, completed []string, opts RunOptions) (err error) {
+	r.checkpoint = newCheckpointer(plan, completed)
+	steps := r.buildSteps(plan, completed)
+
+	if len(steps) == 0 && len(completed) == 0 {
 		return errors.New("no steps to execute")
 	}
 
-	if err := runInstallUI(ctx, steps); err != nil {
+	graph, err := newInstallGraph(steps, completed)
+	if err != nil {
+		return err
+	}
+
+	var report installReporter
+	if opts.Events != nil {
+		events := newJSONReporter(opts.Events, steps)
+		events.runStarted(plan, completed)
+		defer func() { events.runFinished(plan.Config, err) }()
+		report = events
+	}
+
+	if err := r.checkPrerequisites(ctx, plan.Config); err != nil {
+		return err
+	}
+
+	r.projectStep = projectStep(plan)
+	projectReady := r.projectStep == "" || slices.Contains(completed, r.projectStep)
+	if r.log, err = openInstallLog(opts.LogFile, plan.ProjectPath, projectReady); err != nil {
 		return err
 	}
+	defer r.log.Close()
+
+	if len(steps) > 0 {
+		if err := runInstall(ctx, graph, opts, report); err != nil {
+			r.handleFailure(ctx, opts)
+			r.logger.Error("Install failed. Full log:", "path", r.log.Path())
+			return err
+		}
+	}
+
+	// The log stays in the finished project; keep it out of its commits.
+	if opts.LogFile == "" {
+		if err := ignoreStateDir(plan.ProjectPath); err != nil {
+			r.logger.Warn("could not add .ekko/ to .gitignore", "err", err)
+		}
+	}
+	if err := r.checkpoint.clear(); err != nil {
+		r.logger.Warn("could not remove checkpoint", "err", err)
+	}
+	r.logger.Info("Install log:", "path", r.log.Path())
 
-	projectPath := filepath.Join(runner.cwd, cfg.ProjectName)
-	runner.openVSCode(projectPath)
-	runner.printNextSteps(cfg.ProjectName)
+	r.openVSCode(plan.ProjectPath)
+	r.printNextSteps(plan.Config)
 
 	return nil
 }
 
+// checkPrerequisites runs the same checks as `create-ekko-app doctor` so a
+// missing tool is reported before any step has touched the filesystem.
+func (r *runner) checkPrerequisites(ctx context.Context, cfg options.Config) error {
+	results := r.checker.Check(ctx, cfg.PackageManager)
+	for _, result := range results {
+		if result.Status == doctor.StatusWarn {
+			r.logger.Warn(result.Name, "detail", result.Detail)
+		}
+	}
+	if doctor.HasFailures(results) {
+		return fmt.Errorf("missing prerequisites: %s (run `create-ekko-app doctor` for details)", doctor.Failures(results))
+	}
+	return nil
+}
+
+// logStream says where a piece of step output came from.
+type logStream string
+
+const (
+	streamStdout logStream = "stdout"
+	streamStderr logStream = "stderr"
+	// streamStatus carries the installer's own messages, such as the echoed
+	// command line or a hint.
+	streamStatus logStream = "status"
+)
+
+// outputFunc receives step output: whole lines, possibly several at once, or,
+// for interactive steps, the start of a line that is still being written
+// (see copyOutput).
+type outputFunc func(stream logStream, text string)
+
 type installStep struct {
+	id    string
 	title string
-	run   func(context.Context, func(string)) error
+	// needs lists the ids of steps that must finish first.
+	needs []string
+	// optional steps can be skipped after a failure and are skipped when a
+	// need did not succeed; hint is logged when that happens.
+	optional bool
+	hint     string
+	// weight is the step's share of the progress bar; phases are the
+	// milestones its output is expected to print.
+	weight float64
+	phases []progressPhase
+	// interactive steps may prompt; see Step.Interactive.
+	interactive bool
+	run         func(context.Context, outputFunc) error
 }
 
 type runner struct {
-	ctx    context.Context
-	logger *log.Logger
-	cwd    string
+	ctx        context.Context
+	logger     *log.Logger
+	cwd        string
+	executor   executor
+	checker    *doctor.Checker
+	created    *artifacts
+	checkpoint *checkpointer
+	log        *installLog
+	// projectStep is the ID of the step that creates the project directory.
+	projectStep string
 }
 
-func newRunner(ctx context.Context, logger *log.Logger) (*runner, error) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("resolve working directory: %w", err)
-	}
-
+func newRunner(ctx context.Context, logger *log.Logger, cwd string) *runner {
 	return &runner{
-		ctx:    ctx,
-		logger: logger,
-		cwd:    cwd,
-	}, nil
+		ctx:      ctx,
+		logger:   logger,
+		cwd:      cwd,
+		executor: systemExecutor{},
+		checker:  doctor.New(),
+		created:  newArtifacts(cwd),
+	}
 }
 
-func (r *runner) buildSteps(cfg options.Config) ([]installStep, error) {
-	projectPath := filepath.Join(r.cwd, cfg.ProjectName)
-	var steps []installStep
-
-	projectReady := false
-
-	steps = append(steps, installStep{
-		title: fmt.Sprintf("Create %s project", describeFramework(cfg.Framework)),
-		run: func(ctx context.Context, write func(string)) error {
-			if err := r.scaffoldFramework(write, cfg); err != nil {
-				return err
-			}
-			if err := r.ensureProjectPath(projectPath); err != nil {
-				return err
-			}
-			projectReady = true
-			return nil
-		},
-	})
-
-	if deps := collectDependencies(cfg); len(deps) > 0 {
+// buildSteps turns plan steps into runnable installer steps, leaving out the
+// ones listed in completed. Ordering and failure handling are left to the
+// install graph; run only executes the command and records the checkpoint.
+func (r *runner) buildSteps(plan Plan, completed []string) []installStep {
+	steps := make([]installStep, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		if slices.Contains(completed, step.ID) {
+			continue
+		}
 		steps = append(steps, installStep{
-			title: "Install selected dependencies",
-			run: func(ctx context.Context, write func(string)) error {
-				if !projectReady {
-					return errors.New("project directory missing; previous step failed")
+			id:          step.ID,
+			title:       step.Title,
+			needs:       step.Needs,
+			optional:    step.SoftFail,
+			hint:        step.Hint,
+			weight:      step.Weight,
+			phases:      progressPhases(step),
+			interactive: step.Interactive,
+			run: func(ctx context.Context, write outputFunc) (err error) {
+				write = r.log.tee(step.ID, step.Title, write)
+				defer func() { r.log.result(step.ID, err) }()
+
+				if step.Creates != "" {
+					defer r.created.track(step.Creates)()
+				}
+
+				if step.Builtin != "" {
+					err = runBuiltin(step, write)
+				} else {
+					write(streamStatus, fmt.Sprintf("$ %s %s\n", step.Command, strings.Join(step.Args, " ")))
+					err = r.executor.run(ctx, write, step.Dir, step.Command, step.Args...)
+				}
+				if err == nil && step.Creates != "" {
+					err = ensureCreated(step.Creates)
+				}
+				if err != nil {
+					return err
+				}
+
+				if r.checkpoint != nil {
+					if err := r.checkpoint.complete(step.ID); err != nil {
+						write(streamStatus, fmt.Sprintf("⚠️ Could not save progress: %v\n", err))
+					}
 				}
-				return r.installDependencies(projectPath, deps, write)
+				if step.ID == r.projectStep {
+					if err := r.log.moveIntoProject(); err != nil {
+						write(streamStatus, fmt.Sprintf("⚠️ Could not move the install log into the project: %v\n", err))
+					}
+				}
+				return nil
 			},
 		})
 	}
 
-	steps = append(steps, r.shadcnSteps(projectPath, cfg)...)
-
-	return steps, nil
+	return steps
 }
 
 func describeFramework(f options.Framework) string {
@@ -117,95 +313,69 @@ func describeFramework(f options.Framework) string {
 	return "Next.js"
 }
 
-func (r *runner) scaffoldFramework(write func(string), cfg options.Config) error {
-	switch cfg.Framework {
-	case options.FrameworkTanstackStart:
-		return r.exec(write, "", "pnpm", "create", "@tanstack/start@latest", cfg.ProjectName)
-	default:
-		return r.exec(write,
-			"",
-			"pnpm",
-			"dlx",
-			"create-next-app@latest",
-			"--yes",
-			cfg.ProjectName,
-			"--app",
-			"--ts",
-			"--tailwind",
-			"--eslint",
-			"--turbopack",
-			"--src-dir",
-			"--use-pnpm",
-			"--import-alias",
-			"@/*",
-		)
-	}
-}
-
-func (r *runner) ensureProjectPath(path string) error {
+func ensureCreated(path string) error {
 	if _, err := os.Stat(path); err != nil {
-		return fmt.Errorf("project directory missing at %s: %w", path, err)
+		return fmt.Errorf("%s was not created: %w", path, err)
 	}
 	return nil
 }
 
-func (r *runner) installDependencies(projectPath string, deps []string, write func(string)) error {
-	if len(deps) == 0 {
-		return nil
+// handleFailure offers to remove what a failed run created. It never touches
+// paths that existed before the run started.
+func (r *runner) handleFailure(ctx context.Context, opts RunOptions) {
+	paths := r.created.Paths()
+	if len(paths) == 0 {
+		return
 	}
 
-	args := append([]string{"add"}, deps...)
-	return r.exec(write, projectPath, "pnpm", args...)
-}
-
-func (r *runner) shadcnSteps(projectPath string, cfg options.Config) []installStep {
-	if cfg.SkipShadcnOps || !hasTool(cfg.Tooling, options.ToolShadcn) {
-		return nil
+	remove := opts.CleanupOnFailure
+	if !remove && opts.Interactive {
+		confirmed, err := confirmRollback(context.WithoutCancel(ctx), paths)
+		if err != nil {
+			r.logger.Warn("rollback prompt failed", "err", err)
+		}
+		remove = confirmed
 	}
 
-	color := defaultColor(cfg.ShadcnColor)
-	shadcnReady := true
-
-	initStep := installStep{
-		title: fmt.Sprintf("Initialize shadcn (%s)", color),
-		run: func(ctx context.Context, write func(string)) error {
-			err := r.exec(write, projectPath, "pnpm", "dlx", "shadcn@latest", "init", "-y", "--base-color", color)
-			if err != nil {
-				shadcnReady = false
-				write("⚠️ shadcn init failed. You can rerun: pnpm dlx shadcn@latest init\n")
-				return nil
-			}
-			return nil
-		},
+	if !remove {
+		r.logger.Warn("Partially created files were left in place. Remove them or rerun with --cleanup-on-failure.",
+			"paths", strings.Join(paths, ", "))
+		return
 	}
 
-	addStep := installStep{
-		title: "Install shadcn components",
-		run: func(ctx context.Context, write func(string)) error {
-			if !shadcnReady {
-				write("ℹ️ Skipping component installation because shadcn init failed.\n")
-				return nil
-			}
-			err := r.exec(write, projectPath, "pnpm", "dlx", "shadcn@latest", "add", "--all", "-y")
-			if err != nil {
-				write("⚠️ shadcn component install failed. You can rerun: pnpm dlx shadcn@latest add --all\n")
-				return nil
-			}
-			return nil
-		},
+	if err := r.log.moveOutOf(paths); err != nil {
+		r.logger.Warn("could not keep the install log", "err", err)
+	}
+	if err := r.created.rollback(); err != nil {
+		r.logger.Error("rollback failed", "err", err)
+		return
 	}
+	r.logger.Info("Removed partially created files.", "paths", strings.Join(paths, ", "))
+}
 
-	return []installStep{initStep, addStep}
+func confirmRollback(ctx context.Context, paths []string) (bool, error) {
+	remove := true
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Setup did not finish. Remove what this run created?").
+				Description(strings.Join(paths, "\n")).
+				Affirmative("Remove").
+				Negative("Keep").
+				Value(&remove),
+		),
+	).
+		WithTheme(huh.ThemeCharm()).
+		RunWithContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	return remove, nil
 }
 
 func (r *runner) openVSCode(projectPath string) {
-	cmd := exec.CommandContext(r.ctx, "code", ".")
-	cmd.Dir = projectPath
-	cmd.Stdout = io.Discard
-	cmd.Stderr = io.Discard
-	cmd.Stdin = nil
-
-	if err := cmd.Run(); err != nil {
+	discard := func(logStream, string) {}
+	if err := r.executor.run(r.ctx, discard, projectPath, "code", "."); err != nil {
 		r.logger.Info("VS Code command-line tool not found. To open the project, run:",
 			"hint", fmt.Sprintf("cd %s && code .", projectPath))
 		return
@@ -214,56 +384,11 @@ func (r *runner) openVSCode(projectPath string) {
 	r.logger.Info("Opened in VS Code (code .).")
 }
 
-func (r *runner) printNextSteps(projectName string) {
+func (r *runner) printNextSteps(cfg options.Config) {
 	r.logger.Info("Done! Your app is ready.")
 	r.logger.Info("Next steps:")
-	r.logger.Infof("  cd %s", projectName)
-	r.logger.Info("  pnpm dev")
-}
-
-func (r *runner) exec(write func(string), dir string, name string, args ...string) error {
-	cmd := exec.CommandContext(r.ctx, name, args...)
-	if dir != "" {
-		cmd.Dir = dir
-	}
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return err
-	}
-	cmd.Stdin = os.Stdin
-
-	write(fmt.Sprintf("$ %s %s\n", name, strings.Join(args, " ")))
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("start %s: %w", name, err)
-	}
-
-	var wg sync.WaitGroup
-	copyPipe := func(r io.Reader) {
-		defer wg.Done()
-		scanner := bufio.NewScanner(r)
-		for scanner.Scan() {
-			write(scanner.Text() + "\n")
-		}
-		if err := scanner.Err(); err != nil {
-			write(err.Error() + "\n")
-		}
-	}
-
-	wg.Add(2)
-	go copyPipe(stdout)
-	go copyPipe(stderr)
-	wg.Wait()
-
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("run %s %s: %w", name, strings.Join(args, " "), err)
-	}
-
-	return nil
+	r.logger.Infof("  cd %s", filepath.ToSlash(cfg.TargetDir()))
+	r.logger.Infof("  %s", newPackageManager(cfg.PackageManager).run("dev"))
 }
 
 func collectDependencies(cfg options.Config) []string {
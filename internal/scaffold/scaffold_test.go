@@ -1,9 +1,21 @@
 package scaffold
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
+	"github.com/charmbracelet/log"
+
+	"github.com/mikekenway/create-ekko-app/internal/doctor"
 	"github.com/mikekenway/create-ekko-app/internal/options"
 )
 
@@ -72,3 +84,435 @@ func TestDefaultColor(t *testing.T) {
 		t.Fatalf("expected stone, got %s", got)
 	}
 }
+
+// toolsInstalled is a doctor.Checker that finds every prerequisite.
+func toolsInstalled() *doctor.Checker {
+	return &doctor.Checker{
+		LookPath: func(file string) (string, error) { return "/usr/bin/" + file, nil },
+		Output: func(_ context.Context, name string, _ ...string) (string, error) {
+			if name == "node" {
+				return "v22.3.0", nil
+			}
+			return "9.12.1", nil
+		},
+	}
+}
+
+// fakeRunner returns a runner rooted in a temporary directory whose
+// commands go to the returned fake.
+func fakeRunner(t *testing.T, ctx context.Context) (*runner, *fakeExecutor) {
+	t.Helper()
+	r := newRunner(ctx, log.New(io.Discard), t.TempDir())
+	f := newFakeExecutor(t)
+	r.executor = f
+	r.checker = toolsInstalled()
+	return r, f
+}
+
+// headless runs the install without a terminal, reporting to events as
+// --output=jsonl does, and keeps the install log out of the project.
+func headless(t *testing.T, events io.Writer) RunOptions {
+	return RunOptions{Events: events, LogFile: filepath.Join(t.TempDir(), "install.log")}
+}
+
+// createProject stands in for a framework scaffolder.
+func createProject(path string) func() error {
+	return func() error {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return err
+		}
+		pkg := fmt.Sprintf("{\n  \"name\": %q\n}\n", filepath.Base(path))
+		return os.WriteFile(filepath.Join(path, "package.json"), []byte(pkg), 0o644)
+	}
+}
+
+// pinned returns pkg at the version the built-in manifest pins it to.
+func pinned(pkg string) string {
+	return packageSpec(DefaultVersions(), pkg)
+}
+
+// scaffoldCommands lists the commands scaffolding cfg with pnpm from root
+// should run, keyed by plan step ID, plus "code" for opening the editor.
+func scaffoldCommands(cfg options.Config, root string) map[string]*fakeCommand {
+	project := filepath.Join(root, cfg.TargetDir())
+	framework := &fakeCommand{dir: root, name: "pnpm", effect: createProject(project)}
+	if cfg.Framework == options.FrameworkTanstackStart {
+		framework.args = []string{"create", initializerSpec(pinned(tanstackScaffolder)), cfg.TargetDir()}
+	} else {
+		framework.args = []string{
+			"dlx", pinned(nextScaffolder), "--yes", cfg.TargetDir(),
+			"--app", "--ts", "--tailwind", "--eslint", "--turbopack", "--src-dir",
+			"--use-pnpm", "--import-alias", "@/*",
+		}
+	}
+
+	cmds := map[string]*fakeCommand{
+		stepFramework: framework,
+		"code":        {dir: project, name: "code", args: []string{"."}},
+	}
+	if deps := collectDependencies(cfg); len(deps) > 0 {
+		args := []string{"add", "--save-exact"}
+		for _, dep := range deps {
+			args = append(args, pinned(dep))
+		}
+		cmds[stepDependencies] = &fakeCommand{dir: project, name: "pnpm", args: args}
+	}
+	if hasTool(cfg.Tooling, options.ToolShadcn) {
+		cmds[stepShadcnInit] = &fakeCommand{dir: project, name: "pnpm", args: []string{"dlx", pinned(shadcnCLI), "init", "-y", "--base-color", "zinc"}}
+		cmds[stepShadcnAdd] = &fakeCommand{dir: project, name: "pnpm", args: []string{"dlx", pinned(shadcnCLI), "add", "--all", "-y"}}
+	}
+	return cmds
+}
+
+func (f *fakeExecutor) expectAll(cmds map[string]*fakeCommand) {
+	for _, cmd := range cmds {
+		f.expect(*cmd)
+	}
+}
+
+// everyConfig returns a config for each framework, auth, database and
+// tooling subset.
+func everyConfig() []options.Config {
+	var configs []options.Config
+	for _, framework := range options.Frameworks {
+		for _, auth := range options.AuthChoices {
+			for _, database := range options.DatabaseChoices {
+				for mask := 0; mask < 1<<len(options.ToolingOptions); mask++ {
+					tooling := []options.ToolingOption{}
+					for i, tool := range options.ToolingOptions {
+						if mask&(1<<i) != 0 {
+							tooling = append(tooling, tool)
+						}
+					}
+					configs = append(configs, options.Config{
+						ProjectName:    "app",
+						Framework:      framework,
+						Auth:           auth,
+						Database:       database,
+						Tooling:        tooling,
+						PackageManager: options.PackageManagerPnpm,
+					})
+				}
+			}
+		}
+	}
+	return configs
+}
+
+func configName(cfg options.Config) string {
+	tools := make([]string, len(cfg.Tooling))
+	for i, tool := range cfg.Tooling {
+		tools[i] = string(tool)
+	}
+	if len(tools) == 0 {
+		tools = []string{"no-tooling"}
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", cfg.Framework, cfg.Auth, cfg.Database, strings.Join(tools, "+"))
+}
+
+func TestCheckProjectPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "web")
+	if err := checkProjectPath(dir, false); err != nil {
+		t.Fatalf("a missing directory must be accepted: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkProjectPath(dir, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected a non-empty directory to need --force, got %v", err)
+	}
+	if err := checkProjectPath(dir, true); err != nil {
+		t.Fatalf("--force must accept a directory with only repository files: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkProjectPath(dir, true); err == nil || !strings.Contains(err.Error(), "package.json") {
+		t.Fatalf("expected --force to refuse a directory the scaffolder would reject, got %v", err)
+	}
+}
+
+func TestExecuteEveryCombination(t *testing.T) {
+	for _, cfg := range everyConfig() {
+		t.Run(configName(cfg), func(t *testing.T) {
+			t.Parallel()
+			r, f := fakeRunner(t, t.Context())
+			plan, err := BuildPlan(cfg, r.cwd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.expectAll(scaffoldCommands(cfg, r.cwd))
+
+			if err := r.execute(t.Context(), plan, nil, headless(t, io.Discard)); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(f.calls) == 0 || f.calls[0] != commandLine(plan.Steps[0].Command, plan.Steps[0].Args) {
+				t.Fatalf("the scaffolder must run first, got %q", f.calls)
+			}
+			if last := f.calls[len(f.calls)-1]; last != "code ." {
+				t.Fatalf("the editor must open last, got %q", f.calls)
+			}
+			if _, err := os.Stat(filepath.Join(plan.ProjectPath, StateDir, checkpointFile)); !os.IsNotExist(err) {
+				t.Fatalf("a finished run must remove its checkpoint: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecuteTranslatesPackageManager(t *testing.T) {
+	tests := []struct {
+		pm        options.PackageManager
+		framework fakeCommand
+		deps      fakeCommand
+	}{
+		{
+			pm:        options.PackageManagerNpm,
+			framework: fakeCommand{name: "npx", args: []string{"--yes", pinned(nextScaffolder), "--yes", "app", "--app", "--ts", "--tailwind", "--eslint", "--turbopack", "--src-dir", "--use-npm", "--import-alias", "@/*"}},
+			deps:      fakeCommand{name: "npm", args: []string{"install", "--save-exact", pinned("@clerk/nextjs")}},
+		},
+		{
+			pm:        options.PackageManagerBun,
+			framework: fakeCommand{name: "bunx", args: []string{pinned(nextScaffolder), "--yes", "app", "--app", "--ts", "--tailwind", "--eslint", "--turbopack", "--src-dir", "--use-bun", "--import-alias", "@/*"}},
+			deps:      fakeCommand{name: "bun", args: []string{"add", "--exact", pinned("@clerk/nextjs")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.pm), func(t *testing.T) {
+			r, f := fakeRunner(t, t.Context())
+			cfg := options.Config{
+				ProjectName:    "app",
+				Framework:      options.FrameworkNext,
+				Auth:           options.AuthClerk,
+				Database:       options.DatabaseNone,
+				Tooling:        []options.ToolingOption{},
+				PackageManager: tt.pm,
+			}
+			plan, err := BuildPlan(cfg, r.cwd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			project := filepath.Join(r.cwd, "app")
+			tt.framework.dir, tt.framework.effect = r.cwd, createProject(project)
+			tt.deps.dir = project
+			f.expect(tt.framework)
+			f.expect(tt.deps)
+			f.expect(fakeCommand{dir: project, name: "code", args: []string{"."}})
+
+			if err := r.execute(t.Context(), plan, nil, headless(t, io.Discard)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestExecuteNestedDirectoryRenamesPackage(t *testing.T) {
+	r, f := fakeRunner(t, t.Context())
+	cfg := options.Config{
+		ProjectName:    "@acme/web",
+		Directory:      "apps/web",
+		Framework:      options.FrameworkNext,
+		Auth:           options.AuthNone,
+		Database:       options.DatabaseNone,
+		Tooling:        []options.ToolingOption{},
+		PackageManager: options.PackageManagerPnpm,
+	}
+	plan, err := BuildPlan(cfg, r.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.expectAll(scaffoldCommands(cfg, r.cwd))
+
+	if err := r.execute(t.Context(), plan, nil, headless(t, io.Discard)); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := os.ReadFile(filepath.Join(r.cwd, "apps", "web", "package.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(pkg), `"name": "@acme/web"`) {
+		t.Fatalf("expected the package to be renamed:\n%s", pkg)
+	}
+}
+
+// shadcnConfig is the smallest config with shadcn steps.
+var shadcnConfig = options.Config{
+	ProjectName:    "app",
+	Framework:      options.FrameworkNext,
+	Auth:           options.AuthNone,
+	Database:       options.DatabaseNone,
+	Tooling:        []options.ToolingOption{options.ToolShadcn},
+	PackageManager: options.PackageManagerPnpm,
+}
+
+// stepEvents returns the step-finish events in stream, keyed by step ID.
+func stepEvents(t *testing.T, stream []byte) map[string]event {
+	t.Helper()
+	finished := map[string]event{}
+	dec := json.NewDecoder(bytes.NewReader(stream))
+	for dec.More() {
+		var ev event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Type == eventStepFinish {
+			finished[ev.Step] = ev
+		}
+	}
+	return finished
+}
+
+func TestExecuteKeepsInstallLogInProject(t *testing.T) {
+	r, f := fakeRunner(t, t.Context())
+	plan, err := BuildPlan(shadcnConfig, r.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.expectAll(scaffoldCommands(shadcnConfig, r.cwd))
+
+	if err := r.execute(t.Context(), plan, nil, RunOptions{Events: io.Discard}); err != nil {
+		t.Fatal(err)
+	}
+
+	logPath := filepath.Join(plan.ProjectPath, StateDir, installLogFile)
+	if r.log.Path() != logPath {
+		t.Fatalf("log path = %s, want %s", r.log.Path(), logPath)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("a finished project must keep its install log: %v", err)
+	}
+	if !strings.Contains(string(data), " shadcn-add status ✓ succeeded\n") {
+		t.Fatalf("expected the whole run in the log:\n%s", data)
+	}
+	ignore, err := os.ReadFile(filepath.Join(plan.ProjectPath, ".gitignore"))
+	if err != nil || !strings.Contains(string(ignore), "/.ekko/\n") {
+		t.Fatalf("expected .ekko/ in .gitignore, got %q (%v)", ignore, err)
+	}
+}
+
+func TestExecuteShadcnInitSoftFailure(t *testing.T) {
+	r, f := fakeRunner(t, t.Context())
+	plan, err := BuildPlan(shadcnConfig, r.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := scaffoldCommands(shadcnConfig, r.cwd)
+	cmds[stepShadcnInit].stderr = []string{"ERR_PNPM_FETCH_404 shadcn not found"}
+	cmds[stepShadcnInit].exitCode = 1
+	// add needs init, so it must not run.
+	delete(cmds, stepShadcnAdd)
+	f.expectAll(cmds)
+
+	var events bytes.Buffer
+	if err := r.execute(t.Context(), plan, nil, headless(t, &events)); err != nil {
+		t.Fatalf("a shadcn failure must not fail the run: %v", err)
+	}
+
+	finished := stepEvents(t, events.Bytes())
+	if init := finished[stepShadcnInit]; init.Status != "skipped" || !init.SoftFailure || init.ExitCode == nil || *init.ExitCode != 1 {
+		t.Fatalf("unexpected shadcn init result: %+v", init)
+	}
+	if add := finished[stepShadcnAdd]; add.Status != "skipped" || add.SoftFailure || add.ExitCode != nil {
+		t.Fatalf("unexpected shadcn add result: %+v", add)
+	}
+	if finished[stepDependencies].Status != "succeeded" {
+		t.Fatalf("dependencies = %+v", finished[stepDependencies])
+	}
+}
+
+func TestExecuteShadcnAddSoftFailure(t *testing.T) {
+	r, f := fakeRunner(t, t.Context())
+	plan, err := BuildPlan(shadcnConfig, r.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := scaffoldCommands(shadcnConfig, r.cwd)
+	cmds[stepShadcnAdd].exitCode = 2
+	f.expectAll(cmds)
+
+	var events bytes.Buffer
+	if err := r.execute(t.Context(), plan, nil, headless(t, &events)); err != nil {
+		t.Fatalf("a shadcn failure must not fail the run: %v", err)
+	}
+
+	finished := stepEvents(t, events.Bytes())
+	if finished[stepShadcnInit].Status != "succeeded" {
+		t.Fatalf("shadcn init = %+v", finished[stepShadcnInit])
+	}
+	if add := finished[stepShadcnAdd]; add.Status != "skipped" || !add.SoftFailure || *add.ExitCode != 2 {
+		t.Fatalf("unexpected shadcn add result: %+v", add)
+	}
+}
+
+func TestExecuteRequiredFailureRollsBack(t *testing.T) {
+	r, f := fakeRunner(t, t.Context())
+	plan, err := BuildPlan(shadcnConfig, r.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := scaffoldCommands(shadcnConfig, r.cwd)
+	cmds[stepDependencies].exitCode = 1
+	delete(cmds, stepShadcnInit)
+	delete(cmds, stepShadcnAdd)
+	delete(cmds, "code")
+	f.expectAll(cmds)
+
+	opts := headless(t, io.Discard)
+	opts.CleanupOnFailure = true
+	err = r.execute(t.Context(), plan, nil, opts)
+	if code := exitCode(err); code == nil || *code != 1 {
+		t.Fatalf("expected the dependency install's exit status, got %v", err)
+	}
+	if _, err := os.Stat(plan.ProjectPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the project to be rolled back: %v", err)
+	}
+}
+
+func TestExecuteCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	r, f := fakeRunner(t, ctx)
+	plan, err := BuildPlan(shadcnConfig, r.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmds := scaffoldCommands(shadcnConfig, r.cwd)
+	deps := cmds[stepDependencies]
+	deps.block, deps.started = true, make(chan struct{})
+	delete(cmds, stepShadcnInit)
+	delete(cmds, stepShadcnAdd)
+	delete(cmds, "code")
+	f.expectAll(cmds)
+
+	go func() {
+		<-deps.started
+		cancel()
+	}()
+
+	var events bytes.Buffer
+	err = r.execute(ctx, plan, nil, headless(t, &events))
+	if !errors.Is(err, ErrInstallCancelled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if !bytes.Contains(events.Bytes(), []byte(`"status":"cancelled"`)) {
+		t.Fatalf("expected a cancelled run-finish event:\n%s", events.String())
+	}
+	// Without --cleanup-on-failure the project is kept for resume, with the
+	// framework step checkpointed. Recording the versions runs alongside the
+	// install, so it may or may not have finished.
+	state, err := loadCheckpoint(plan.ProjectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := slices.DeleteFunc(slices.Clone(state.Completed), func(id string) bool { return id == stepVersions })
+	if !slices.Equal(completed, []string{stepFramework}) {
+		t.Fatalf("completed = %v", state.Completed)
+	}
+}
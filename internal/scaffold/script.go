@@ -0,0 +1,115 @@
+package scaffold
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// WriteScript renders the plan as a standalone shell script. The body sticks
+// to POSIX sh, but the shebang asks for bash because pipefail is not
+// available in every /bin/sh (dash, for one). The script runs from the
+// directory it is invoked in, mirrors the installer's soft-failure handling
+// (warn and continue), and skips steps whose prerequisites did not complete.
+func (p Plan) WriteScript(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("#!/usr/bin/env bash\n")
+	fmt.Fprintf(&b, "# Generated by create-ekko-app: %s\n", describeConfig(p.Config))
+	b.WriteString("# Run from the directory that should contain the project.\n")
+	b.WriteString("set -euo pipefail\n\n")
+	b.WriteString("ROOT=\"$(pwd)\"\n")
+	fmt.Fprintf(&b, "PROJECT_DIR=%s\n", scriptPath(p.relative(p.ProjectPath)))
+
+	for _, step := range p.Steps {
+		fmt.Fprintf(&b, "%s=0\n", scriptStatusVar(step.ID))
+	}
+
+	titles := make(map[string]string, len(p.Steps))
+	for i, step := range p.Steps {
+		titles[step.ID] = step.Title
+		fmt.Fprintf(&b, "\n# %d. %s\n", i+1, step.Title)
+		fmt.Fprintf(&b, "echo %s\n", shellQuote("==> "+step.Title))
+
+		if !step.SoftFail {
+			for _, need := range step.Needs {
+				fmt.Fprintf(&b, "[ \"$%s\" = 1 ] || { echo %s >&2; exit 1; }\n",
+					scriptStatusVar(need),
+					shellQuote(titles[need]+" did not complete; cannot continue"))
+			}
+			fmt.Fprintf(&b, "cd %s\n", p.scriptDir(step.Dir))
+			fmt.Fprintf(&b, "%s\n", step.CommandLine())
+			if step.Creates != "" {
+				fmt.Fprintf(&b, "[ -d %s ] || { echo %s >&2; exit 1; }\n",
+					p.scriptDir(step.Creates),
+					shellQuote(p.relative(step.Creates)+" was not created"))
+			}
+			fmt.Fprintf(&b, "%s=1\n", scriptStatusVar(step.ID))
+			continue
+		}
+
+		indent := ""
+		if len(step.Needs) > 0 {
+			conds := make([]string, len(step.Needs))
+			for j, need := range step.Needs {
+				conds[j] = fmt.Sprintf("[ \"$%s\" = 1 ]", scriptStatusVar(need))
+			}
+			fmt.Fprintf(&b, "if %s; then\n", strings.Join(conds, " && "))
+			indent = "  "
+		}
+		fmt.Fprintf(&b, "%scd %s\n", indent, p.scriptDir(step.Dir))
+		fmt.Fprintf(&b, "%sif %s; then\n", indent, step.CommandLine())
+		fmt.Fprintf(&b, "%s  %s=1\n", indent, scriptStatusVar(step.ID))
+		fmt.Fprintf(&b, "%selse\n", indent)
+		fmt.Fprintf(&b, "%s  echo %s >&2\n", indent, shellQuote(step.Hint))
+		fmt.Fprintf(&b, "%sfi\n", indent)
+		if len(step.Needs) > 0 {
+			b.WriteString("else\n")
+			fmt.Fprintf(&b, "  echo %s >&2\n", shellQuote(fmt.Sprintf("ℹ️ Skipping %q because a previous step did not complete.", step.Title)))
+			b.WriteString("fi\n")
+		}
+	}
+
+	b.WriteString("\ncd \"$ROOT\"\n")
+	b.WriteString("echo \"Done! Your app is ready in $PROJECT_DIR\"\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// scriptDir renders dir relative to $ROOT for use in the script.
+func (p Plan) scriptDir(dir string) string {
+	if dir == p.ProjectPath {
+		return `"$ROOT/$PROJECT_DIR"`
+	}
+	rel := p.relative(dir)
+	if rel == "." {
+		return `"$ROOT"`
+	}
+	return `"$ROOT"/` + scriptPath(rel)
+}
+
+func scriptPath(rel string) string {
+	return shellQuote(filepath.ToSlash(rel))
+}
+
+func scriptStatusVar(id string) string {
+	return "ok_" + strings.NewReplacer("-", "_", ".", "_").Replace(id)
+}
+
+func describeConfig(cfg options.Config) string {
+	parts := []string{cfg.ProjectName, describeFramework(cfg.Framework)}
+	if cfg.Auth != "" && cfg.Auth != options.AuthNone {
+		parts = append(parts, string(cfg.Auth))
+	}
+	if cfg.Database != "" && cfg.Database != options.DatabaseNone {
+		parts = append(parts, string(cfg.Database))
+	}
+	for _, tool := range cfg.Tooling {
+		parts = append(parts, string(tool))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,77 @@
+package scaffold
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+func TestWriteScriptSoftFailures(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	root := t.TempDir()
+	bin := t.TempDir()
+	fakePnpm := `#!/bin/sh
+echo "pnpm $*" >> "$CALLS"
+case "$*" in
+  *create-next-app*) mkdir -p demo ;;
+  *"shadcn@"*" init"*) exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "pnpm"), []byte(fakePnpm), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := options.Config{
+		ProjectName: "demo",
+		Framework:   options.FrameworkNext,
+		Tooling:     []options.ToolingOption{options.ToolShadcn},
+	}
+	plan, err := BuildPlan(cfg, "/elsewhere")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var script strings.Builder
+	if err := plan.WriteScript(&script); err != nil {
+		t.Fatal(err)
+	}
+	scriptPath := filepath.Join(root, "setup.sh")
+	if err := os.WriteFile(scriptPath, []byte(script.String()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := filepath.Join(root, "calls.log")
+	cmd := exec.Command(bash, scriptPath)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "PATH="+bin+":"+os.Getenv("PATH"), "CALLS="+calls)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+
+	if !strings.Contains(string(out), "shadcn init failed") {
+		t.Fatalf("expected soft-failure warning in output:\n%s", out)
+	}
+	if !strings.Contains(string(out), `Skipping "Install shadcn components"`) {
+		t.Fatalf("expected dependent step to be skipped:\n%s", out)
+	}
+
+	log, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(log), pinned(shadcnCLI)+" add") {
+		t.Fatalf("shadcn add should not run after init failed:\n%s", log)
+	}
+	if !strings.Contains(string(log), "pnpm add --save-exact class-variance-authority") {
+		t.Fatalf("expected dependency install to run:\n%s", log)
+	}
+}
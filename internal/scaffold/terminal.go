@@ -0,0 +1,246 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/creack/pty"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/cancelreader"
+)
+
+// stepTerminal is how the install view, or a headless run with a terminal
+// on stdin (see stdinTerminal), hands the terminal to a step. Steps never
+// read os.Stdin themselves; interactive ones run on a pseudo-terminal
+// instead and receive what is typed through the writer passed to attach.
+type stepTerminal struct {
+	interactive bool
+	cols        int
+	attach      func(input io.Writer)
+}
+
+// terminalRows is the height of the pseudo-terminal interactive steps run
+// on. Prompts only need a few lines.
+const terminalRows = 24
+
+type terminalKey struct{}
+
+func withTerminal(ctx context.Context, term *stepTerminal) context.Context {
+	return context.WithValue(ctx, terminalKey{}, term)
+}
+
+// terminalFrom returns the terminal set up for the step running with ctx,
+// or nil when the step gets no input.
+func terminalFrom(ctx context.Context) *stepTerminal {
+	term, _ := ctx.Value(terminalKey{}).(*stepTerminal)
+	return term
+}
+
+// stdinTerminal passes what is typed on the terminal on stdin to the
+// interactive steps of a run without the install view, such as --plain.
+// Input goes to the step that attached first. The terminal stays in line
+// mode, so each answer is sent when enter is pressed.
+type stdinTerminal struct {
+	file *os.File
+
+	mu     sync.Mutex
+	inputs []io.Writer
+	reader cancelreader.CancelReader
+	done   chan struct{}
+}
+
+// newStdinTerminal returns nil when stdin is not a terminal.
+func newStdinTerminal() *stdinTerminal {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return nil
+	}
+	return &stdinTerminal{file: os.Stdin}
+}
+
+// step returns the terminal for one interactive step and a function to call
+// once the step has returned.
+func (s *stdinTerminal) step() (*stepTerminal, func()) {
+	cols := 80
+	if _, c, err := pty.Getsize(s.file); err == nil && c > 0 {
+		cols = c
+	}
+
+	var input io.Writer
+	term := &stepTerminal{
+		interactive: true,
+		cols:        cols,
+		attach: func(w io.Writer) {
+			input = w
+			s.attach(w)
+		},
+	}
+	return term, func() {
+		if input != nil {
+			s.detach(input)
+		}
+	}
+}
+
+// attach starts reading stdin if no other step is.
+func (s *stdinTerminal) attach(input io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inputs = append(s.inputs, input)
+	if s.reader != nil {
+		return
+	}
+	reader, err := cancelreader.NewReader(s.file)
+	if err != nil {
+		return
+	}
+	s.reader, s.done = reader, make(chan struct{})
+	go s.forward(reader, s.done)
+}
+
+// detach stops reading stdin once no step is attached, so nothing typed
+// afterwards is lost to a finished step.
+func (s *stdinTerminal) detach(input io.Writer) {
+	s.mu.Lock()
+	s.inputs = slices.DeleteFunc(s.inputs, func(w io.Writer) bool { return w == input })
+	if len(s.inputs) > 0 || s.reader == nil {
+		s.mu.Unlock()
+		return
+	}
+	reader, done := s.reader, s.done
+	s.reader = nil
+	s.mu.Unlock()
+
+	reader.Cancel()
+	<-done
+	reader.Close()
+}
+
+func (s *stdinTerminal) forward(reader io.Reader, done chan struct{}) {
+	defer close(done)
+	buf := make([]byte, 1024)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			// Line mode ends an answer with \n; the key is \r on a terminal.
+			data := bytes.ReplaceAll(buf[:n], []byte("\n"), []byte("\r"))
+			s.mu.Lock()
+			if len(s.inputs) > 0 {
+				// The step may have exited; its input then fails to write.
+				_, _ = s.inputs[0].Write(data)
+			}
+			s.mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// outputIdle is how long a partial line waits for the rest of it before it
+// is passed on by itself. Prompts end without a newline while they wait for
+// an answer.
+const outputIdle = 100 * time.Millisecond
+
+// ansiEscape matches the terminal control sequences programs print when
+// they think they are writing to a terminal: colours, cursor movement and
+// window titles.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// copyOutput passes what r produces to write, a line or several at a time.
+// With partial set, as for a step prompting on a pseudo-terminal, a trailing
+// partial line is passed on without its newline once r has been quiet for
+// outputIdle; the rest of the line follows when it arrives. Otherwise write
+// only ever receives whole lines, which is what the plain, jsonl and log
+// consumers expect.
+func copyOutput(r io.Reader, stream logStream, partial bool, write outputFunc) error {
+	chunks := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				chunks <- bytes.Clone(buf[:n])
+			}
+			if err != nil {
+				readErr <- err
+				close(chunks)
+				return
+			}
+		}
+	}()
+
+	var pending []byte
+	idle := time.NewTimer(outputIdle)
+	idle.Stop()
+	defer idle.Stop()
+
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				if len(pending) > 0 {
+					write(stream, cleanOutput(pending)+"\n")
+				}
+				if err := <-readErr; err != io.EOF {
+					return err
+				}
+				return nil
+			}
+			pending = append(pending, chunk...)
+			if end := bytes.LastIndexByte(pending, '\n'); end >= 0 {
+				write(stream, cleanOutput(pending[:end+1]))
+				pending = pending[end+1:]
+			}
+			if partial && len(pending) > 0 {
+				idle.Reset(outputIdle)
+			}
+		case <-idle.C:
+			if len(pending) > 0 {
+				write(stream, cleanOutput(pending))
+				pending = nil
+			}
+		}
+	}
+}
+
+func cleanOutput(b []byte) string {
+	text := strings.ReplaceAll(string(b), "\r\n", "\n")
+	return ansiEscape.ReplaceAllString(text, "")
+}
+
+// keyInput translates a key pressed in the install view into the bytes a
+// terminal would send for it, or nil for keys with no terminal equivalent.
+func keyInput(msg tea.KeyMsg) []byte {
+	switch msg.Type {
+	case tea.KeyRunes:
+		return []byte(string(msg.Runes))
+	case tea.KeySpace:
+		return []byte(" ")
+	case tea.KeyEnter:
+		return []byte("\r")
+	case tea.KeyBackspace:
+		return []byte{0x7f}
+	case tea.KeyTab:
+		return []byte("\t")
+	case tea.KeyEsc:
+		return []byte{0x1b}
+	case tea.KeyUp:
+		return []byte("\x1b[A")
+	case tea.KeyDown:
+		return []byte("\x1b[B")
+	case tea.KeyRight:
+		return []byte("\x1b[C")
+	case tea.KeyLeft:
+		return []byte("\x1b[D")
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/creack/pty"
+)
+
+// outputRecorder collects what an outputFunc is given, chunk by chunk.
+type outputRecorder struct {
+	mu     sync.Mutex
+	chunks []string
+}
+
+func (o *outputRecorder) write(_ logStream, text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.chunks = append(o.chunks, text)
+}
+
+func (o *outputRecorder) joined() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return strings.Join(o.chunks, "")
+}
+
+// waitFor polls until the recorded output contains want.
+func (o *outputRecorder) waitFor(t *testing.T, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(o.joined(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q, got %q", want, o.joined())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCopyOutputPassesOnPartialLines(t *testing.T) {
+	r, w := io.Pipe()
+	var out outputRecorder
+	done := make(chan error)
+	go func() { done <- copyOutput(r, streamStdout, true, out.write) }()
+
+	io.WriteString(w, "\x1b[32m✔\x1b[0m Preflight checks.\r\n? Which color would you like to use? ")
+	out.waitFor(t, "use? ")
+
+	io.WriteString(w, "Neutral\r\nlast line without newline")
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"✔ Preflight checks.\n",
+		"? Which color would you like to use? ",
+		"Neutral\n",
+		"last line without newline\n",
+	}
+	if strings.Join(out.chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("chunks = %q, want %q", out.chunks, want)
+	}
+}
+
+func TestCopyOutputKeepsSlowLinesWhole(t *testing.T) {
+	steps := []installStep{{id: "dependencies", title: "Install dependencies"}}
+	var plain, events bytes.Buffer
+	plainReport := newPlainReporter(&plain, steps, false)
+	jsonReport := newJSONReporter(&events, steps)
+	logPath := filepath.Join(t.TempDir(), "install.log")
+	log, err := openInstallLog(logPath, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	write := log.tee("dependencies", "Install dependencies", func(stream logStream, text string) {
+		plainReport.stepOutput(0, stream, text)
+		jsonReport.stepOutput(0, stream, text)
+	})
+
+	r, w := io.Pipe()
+	done := make(chan error)
+	go func() { done <- copyOutput(r, streamStdout, false, write) }()
+	io.WriteString(w, "Progress: resolved 10, ")
+	time.Sleep(3 * outputIdle)
+	io.WriteString(w, "downloaded 5\n")
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	const line = "Progress: resolved 10, downloaded 5"
+	if got := plain.String(); got != "[dependencies] "+line+"\n" {
+		t.Fatalf("plain output = %q", got)
+	}
+	if got := decodeEvents(t, events.Bytes()); len(got) != 1 || got[0].Line != line {
+		t.Fatalf("expected one log event for the line, got %+v", got)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), " dependencies stdout "); n != 1 || !strings.Contains(string(data), line+"\n") {
+		t.Fatalf("expected the line once in the install log:\n%s", data)
+	}
+}
+
+func TestKeyInput(t *testing.T) {
+	tests := map[string]struct {
+		msg  tea.KeyMsg
+		want string
+	}{
+		"runes": {keyMsg("y"), "y"},
+		"enter": {tea.KeyMsg{Type: tea.KeyEnter}, "\r"},
+		"down":  {tea.KeyMsg{Type: tea.KeyDown}, "\x1b[B"},
+		"f1":    {tea.KeyMsg{Type: tea.KeyF1}, ""},
+	}
+	for name, tt := range tests {
+		if got := string(keyInput(tt.msg)); got != tt.want {
+			t.Errorf("%s: keyInput = %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestExecAnswersPromptOnTerminal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pseudo-terminals need a unix system")
+	}
+
+	var out outputRecorder
+	ctx := withTerminal(t.Context(), &stepTerminal{
+		interactive: true,
+		cols:        80,
+		attach: func(input io.Writer) {
+			go func() {
+				out.waitFor(t, "name? ")
+				io.WriteString(input, "ekko\r")
+			}()
+		},
+	})
+
+	err := systemExecutor{}.run(ctx, out.write, "", "sh", "-c", `[ -t 0 ] && printf 'name? ' && read name && echo "hello $name"`)
+	if err != nil {
+		t.Fatalf("exec: %v\n%s", err, out.joined())
+	}
+	if !strings.Contains(out.joined(), "hello ekko\n") {
+		t.Fatalf("expected the answer to reach the step:\n%s", out.joined())
+	}
+}
+
+func TestExecKeepsStdinFromInstallView(t *testing.T) {
+	var out outputRecorder
+	ctx := withTerminal(context.Background(), &stepTerminal{})
+
+	if err := (systemExecutor{}).run(ctx, out.write, "", "sh", "-c", `read answer || echo no input`); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.joined(), "no input") {
+		t.Fatalf("a step that is not interactive must not read the terminal:\n%s", out.joined())
+	}
+}
+
+func TestPlainInteractiveStepDoesNotReadTerminal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pseudo-terminals need a unix system")
+	}
+	// A terminal on stdin, as for --plain in a shell.
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+	stdin := os.Stdin
+	os.Stdin = tty
+	defer func() { os.Stdin = stdin }()
+
+	steps := []installStep{{
+		id:          "shadcn-init",
+		title:       "Initialize shadcn",
+		interactive: true,
+		run: func(ctx context.Context, write outputFunc) error {
+			return systemExecutor{}.run(ctx, write, "", "sh", "-c", `read answer || echo no input`)
+		},
+	}}
+	graph, err := newInstallGraph(steps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	if err := runHeadless(ctx, graph, newPlainReporter(&out, steps, false), nil); err != nil {
+		t.Fatalf("runHeadless: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "[shadcn-init] no input\n") {
+		t.Fatalf("expected the step to see no input instead of the terminal:\n%s", out.String())
+	}
+}
+
+func TestPlainInteractiveStepAnswersFromTerminal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pseudo-terminals need a unix system")
+	}
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer ptmx.Close()
+	defer tty.Close()
+	stdin := os.Stdin
+	os.Stdin = tty
+	defer func() { os.Stdin = stdin }()
+	term := newStdinTerminal()
+	if term == nil {
+		t.Fatal("expected a terminal on stdin")
+	}
+	// The answer is typed before the step asks; the terminal holds on to it.
+	if _, err := ptmx.Write([]byte("ekko\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []installStep{{
+		id:          "shadcn-init",
+		title:       "Initialize shadcn",
+		interactive: true,
+		run: func(ctx context.Context, write outputFunc) error {
+			return systemExecutor{}.run(ctx, write, "", "sh", "-c", `[ -t 0 ] && printf 'name? ' && read name && echo "hello $name"`)
+		},
+	}}
+	graph, err := newInstallGraph(steps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	if err := runHeadless(ctx, graph, newPlainReporter(&out, steps, false), term); err != nil {
+		t.Fatalf("runHeadless: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "hello ekko") {
+		t.Fatalf("expected the step to read the answer typed on stdin:\n%s", out.String())
+	}
+}
+
+func TestPlainInteractiveStepFailureNeedsTerminal(t *testing.T) {
+	steps := []installStep{{
+		id:          "shadcn-init",
+		title:       "Initialize shadcn",
+		interactive: true,
+		run: func(ctx context.Context, write outputFunc) error {
+			return errors.New("exit status 1")
+		},
+	}}
+	graph, err := newInstallGraph(steps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runHeadless(t.Context(), graph, newPlainReporter(io.Discard, steps, false), nil)
+	if err == nil || !strings.Contains(err.Error(), "needs a terminal") {
+		t.Fatalf("expected the failure to say the step needs a terminal, got %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+#!/usr/bin/env node
+// Stands in for create-next-app and @tanstack/create-start: copies the
+// template packed next to this script into the target directory, the first
+// argument that is not a flag, and names the package after it.
+const fs = require("fs");
+const path = require("path");
+
+const target = process.argv.slice(2).find((arg) => !arg.startsWith("-"));
+if (!target) {
+  console.error("usage: create <directory> [flags]");
+  process.exit(1);
+}
+
+fs.cpSync(path.join(__dirname, "template"), target, { recursive: true });
+
+const pkgPath = path.join(target, "package.json");
+const pkg = JSON.parse(fs.readFileSync(pkgPath, "utf8"));
+pkg.name = path.basename(path.resolve(target));
+fs.writeFileSync(pkgPath, JSON.stringify(pkg, null, 2) + "\n");
+
+console.log(`Success! Created ${pkg.name} at ${path.resolve(target)}`);
@@ -0,0 +1,20 @@
+#!/usr/bin/env node
+// Stands in for the shadcn CLI: init writes components.json and add writes
+// a component, without installing anything.
+const fs = require("fs");
+const path = require("path");
+
+switch (process.argv[2]) {
+  case "init":
+    fs.writeFileSync("components.json", JSON.stringify({ style: "new-york" }, null, 2) + "\n");
+    console.log("✔ Writing components.json.");
+    break;
+  case "add":
+    fs.mkdirSync(path.join("src", "components", "ui"), { recursive: true });
+    fs.writeFileSync(path.join("src", "components", "ui", "button.tsx"), "export function Button() {}\n");
+    console.log("✔ Created 1 file.");
+    break;
+  default:
+    console.error(`unknown command ${process.argv[2]}`);
+    process.exit(1);
+}
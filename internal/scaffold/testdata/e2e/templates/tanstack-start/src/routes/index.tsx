@@ -0,0 +1,3 @@
+export function Home() {
+  return <main>Hello from the offline template</main>;
+}
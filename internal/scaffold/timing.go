@@ -0,0 +1,135 @@
+package scaffold
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// stepTimes records when each step of an install graph started and
+// finished. A retried step keeps its first start time.
+type stepTimes struct {
+	started  []time.Time
+	finished []time.Time
+}
+
+func newStepTimes(n int) *stepTimes {
+	return &stepTimes{
+		started:  make([]time.Time, n),
+		finished: make([]time.Time, n),
+	}
+}
+
+func (t *stepTimes) start(i int, now time.Time) {
+	if t.started[i].IsZero() {
+		t.started[i] = now
+	}
+	t.finished[i] = time.Time{}
+}
+
+func (t *stepTimes) finish(i int, now time.Time) {
+	if !t.started[i].IsZero() {
+		t.finished[i] = now
+	}
+}
+
+// elapsed returns how long step i has run, or ran, and false when it never
+// started.
+func (t *stepTimes) elapsed(i int, now time.Time) (time.Duration, bool) {
+	if t.started[i].IsZero() {
+		return 0, false
+	}
+	end := t.finished[i]
+	if end.IsZero() {
+		end = now
+	}
+	return end.Sub(t.started[i]), true
+}
+
+// total returns the wall time from the first step starting to the last one
+// finishing, which is less than the sum of the steps when they overlap.
+func (t *stepTimes) total(now time.Time) time.Duration {
+	var first, last time.Time
+	for i, started := range t.started {
+		if started.IsZero() {
+			continue
+		}
+		if first.IsZero() || started.Before(first) {
+			first = started
+		}
+		end := t.finished[i]
+		if end.IsZero() {
+			end = now
+		}
+		if end.After(last) {
+			last = end
+		}
+	}
+	return last.Sub(first)
+}
+
+// formatElapsed renders d with a tenth of a second precision under a minute
+// and whole seconds above.
+func formatElapsed(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
+
+// statusIcon is the marker shown next to a step in the step list and the
+// timing summary.
+func statusIcon(status stepStatus) string {
+	switch status {
+	case stepRunning:
+		return "•"
+	case stepSucceeded:
+		return "✓"
+	case stepSkipped:
+		return "⚠"
+	case stepAwaiting, stepFailed:
+		return "✗"
+	default:
+		return "○"
+	}
+}
+
+func statusLabel(status stepStatus) string {
+	switch status {
+	case stepRunning:
+		return "running"
+	case stepAwaiting:
+		return "failed"
+	case stepSucceeded:
+		return "done"
+	case stepSkipped:
+		return "skipped"
+	case stepFailed:
+		return "failed"
+	default:
+		return "not run"
+	}
+}
+
+// writeTimingSummary prints how long each step took, so slow installs show
+// where the time went.
+func writeTimingSummary(w io.Writer, graph *installGraph, times *stepTimes, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tSTATUS\tTIME")
+	for i, step := range graph.steps {
+		elapsed := "-"
+		if d, ok := times.elapsed(i, now); ok {
+			elapsed = formatElapsed(d)
+		}
+		status := graph.status[i]
+		icon, label := statusIcon(status), statusLabel(status)
+		if status == stepRunning {
+			// The run stopped while this step was still going.
+			icon, label = statusIcon(stepFailed), "interrupted"
+		}
+		fmt.Fprintf(tw, "%s\t%s %s\t%s\n", step.title, icon, label, elapsed)
+	}
+	fmt.Fprintf(tw, "Total\t\t%s\n", formatElapsed(times.total(now)))
+	return tw.Flush()
+}
@@ -0,0 +1,90 @@
+package scaffold
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTimingSummary(t *testing.T) {
+	graph, err := newInstallGraph([]installStep{
+		{id: "framework", title: "Create Next.js project"},
+		{id: "dependencies", title: "Install selected dependencies", needs: []string{"framework"}},
+		{id: "versions", title: "Record package versions", needs: []string{"framework"}},
+		{id: "shadcn-init", title: "Initialize shadcn", needs: []string{"dependencies"}, optional: true},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	times := newStepTimes(4)
+	times.start(0, base)
+	times.finish(0, base.Add(72*time.Second))
+	times.start(1, base.Add(72*time.Second))
+	times.start(2, base.Add(72*time.Second))
+	times.finish(2, base.Add(72*time.Second+300*time.Millisecond))
+	graph.status = []stepStatus{stepSucceeded, stepRunning, stepSucceeded, stepPending}
+
+	var out bytes.Buffer
+	if err := writeTimingSummary(&out, graph, times, base.Add(90*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `STEP                           STATUS         TIME
+Create Next.js project         ✓ done         1m12s
+Install selected dependencies  ✗ interrupted  18.0s
+Record package versions        ✓ done         0.3s
+Initialize shadcn              ○ not run      -
+Total                                         1m30s
+`
+	if out.String() != want {
+		t.Fatalf("unexpected summary:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestStepTimesKeepFirstStartOnRetry(t *testing.T) {
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	times := newStepTimes(1)
+	times.start(0, base)
+	times.finish(0, base.Add(time.Second))
+	times.start(0, base.Add(5*time.Second))
+
+	if d, ok := times.elapsed(0, base.Add(8*time.Second)); !ok || d != 8*time.Second {
+		t.Fatalf("elapsed = %v (%v), want 8s", d, ok)
+	}
+}
+
+func TestInstallModelStepList(t *testing.T) {
+	graph, err := newInstallGraph([]installStep{
+		{id: "framework", title: "Create Next.js project"},
+		{id: "versions", title: "Record package versions"},
+		{id: "shadcn", title: "Initialize shadcn"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newInstallModel(t.Context(), graph)
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	m.now = func() time.Time { return base.Add(3 * time.Second) }
+	m.times.start(0, base)
+	m.times.finish(0, base.Add(2*time.Second))
+	graph.status[0] = stepSucceeded
+	m.times.start(1, base.Add(time.Second))
+	graph.status[1] = stepRunning
+
+	lines := strings.Split(m.stepListView(), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected one line per step, got %q", lines)
+	}
+	if !strings.Contains(lines[0], "✓ Create Next.js project") || !strings.HasSuffix(lines[0], "2.0s") {
+		t.Fatalf("unexpected finished line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], m.spinner.View()) || !strings.HasSuffix(lines[1], "2.0s") {
+		t.Fatalf("unexpected running line %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "○ Initialize shadcn") {
+		t.Fatalf("unexpected pending line %q", lines[2])
+	}
+}
@@ -0,0 +1,107 @@
+package scaffold
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+// manifestJSON pins every package a plan can install: the scaffolders and
+// each dependency collectDependencies can pick. Bump it deliberately, so the
+// same selections scaffold the same project until then.
+//
+//go:embed versions.json
+var manifestJSON []byte
+
+// Package names of the scaffolders. The TanStack Start initializer is run as
+// `<pm> create @tanstack/start`, which installs @tanstack/create-start.
+const (
+	nextScaffolder     = "create-next-app"
+	tanstackScaffolder = "@tanstack/create-start"
+	shadcnCLI          = "shadcn"
+)
+
+// latestVersion is what every package resolves to under --latest, and the
+// fallback for one the manifest does not list.
+const latestVersion = "latest"
+
+// versionsFile is where a scaffolded project records the versions it was
+// created with, at its root so it is committed with it. It doubles as a
+// --versions file.
+const versionsFile = "ekko.versions.json"
+
+// DefaultVersions returns the versions manifest built into the CLI.
+func DefaultVersions() map[string]string {
+	var versions map[string]string
+	if err := json.Unmarshal(manifestJSON, &versions); err != nil {
+		panic("scaffold: invalid embedded versions.json: " + err.Error())
+	}
+	return versions
+}
+
+// planPackages lists the npm packages scaffolding cfg installs or runs.
+func planPackages(cfg options.Config) []string {
+	packages := []string{nextScaffolder}
+	if cfg.Framework == options.FrameworkTanstackStart {
+		packages[0] = tanstackScaffolder
+	}
+	packages = append(packages, collectDependencies(cfg)...)
+	if hasShadcnSteps(cfg) {
+		packages = append(packages, shadcnCLI)
+	}
+	return packages
+}
+
+// resolveVersions returns the version each package cfg uses is installed at:
+// cfg.Versions over the built-in manifest, or latest for all of them under
+// cfg.Latest.
+func resolveVersions(cfg options.Config) map[string]string {
+	manifest := DefaultVersions()
+	versions := map[string]string{}
+	for _, pkg := range planPackages(cfg) {
+		switch {
+		case cfg.Latest:
+			versions[pkg] = latestVersion
+		case cfg.Versions[pkg] != "":
+			versions[pkg] = cfg.Versions[pkg]
+		case manifest[pkg] != "":
+			versions[pkg] = manifest[pkg]
+		default:
+			versions[pkg] = latestVersion
+		}
+	}
+	return versions
+}
+
+// packageSpec returns pkg at its resolved version, e.g. clsx@2.1.1.
+func packageSpec(versions map[string]string, pkg string) string {
+	version := versions[pkg]
+	if version == "" {
+		version = latestVersion
+	}
+	return pkg + "@" + version
+}
+
+// versionsStep records versions in the project, so it shows what it was
+// scaffolded with after the checkpoint is gone.
+func versionsStep(projectPath string, versions map[string]string) Step {
+	data, _ := json.MarshalIndent(versions, "", "  ")
+	return Step{
+		ID:      stepVersions,
+		Title:   "Record package versions",
+		Command: "sh",
+		Args:    []string{"-c", writeFileScript, "sh", versionsFile, string(data) + "\n"},
+		Dir:     projectPath,
+		Needs:   []string{stepFramework},
+		Builtin: builtinWriteFile,
+	}
+}
+
+// initializerSpec turns the scaffolder package spec into the initializer
+// `<pm> create` expects: @tanstack/create-start@1.0.0 becomes
+// @tanstack/start@1.0.0.
+func initializerSpec(spec string) string {
+	return strings.Replace(spec, "/create-", "/", 1)
+}
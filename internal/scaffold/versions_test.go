@@ -0,0 +1,150 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mikekenway/create-ekko-app/internal/options"
+)
+
+func TestDefaultVersionsPinEveryPackage(t *testing.T) {
+	manifest := DefaultVersions()
+	if err := options.ValidateVersions(manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	used := map[string]bool{}
+	for _, cfg := range everyConfig() {
+		for _, pkg := range planPackages(cfg) {
+			used[pkg] = true
+			if manifest[pkg] == "" || manifest[pkg] == latestVersion {
+				t.Errorf("%s is not pinned in versions.json", pkg)
+			}
+		}
+	}
+	for pkg := range manifest {
+		if !used[pkg] {
+			t.Errorf("versions.json pins %s, which no plan uses", pkg)
+		}
+	}
+}
+
+func TestBuildPlanVersions(t *testing.T) {
+	cfg := options.Config{
+		ProjectName: "demo",
+		Framework:   options.FrameworkTanstackStart,
+		Auth:        options.AuthClerk,
+		Tooling:     []options.ToolingOption{options.ToolShadcn},
+		Versions:    map[string]string{"@clerk/clerk-react": "^5.40.0", "lucide-react": "latest"},
+	}
+
+	plan, err := BuildPlan(cfg, "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := map[string][]string{}
+	for _, step := range plan.Steps {
+		args[step.ID] = step.Args
+	}
+	if !slices.Contains(args[stepFramework], "@tanstack/start@"+DefaultVersions()[tanstackScaffolder]) {
+		t.Fatalf("expected the pinned scaffolder, got %v", args[stepFramework])
+	}
+	for _, want := range []string{"@clerk/clerk-react@^5.40.0", "lucide-react@latest", pinned("clsx")} {
+		if !slices.Contains(args[stepDependencies], want) {
+			t.Fatalf("expected %s in %v", want, args[stepDependencies])
+		}
+	}
+	if !slices.Contains(args[stepShadcnInit], pinned(shadcnCLI)) {
+		t.Fatalf("expected the pinned shadcn CLI, got %v", args[stepShadcnInit])
+	}
+
+	// The plan keeps exactly the versions it uses, which is what the
+	// checkpoint stores, so rebuilding it from its own config changes nothing.
+	if !slices.Equal(slices.Sorted(maps.Keys(plan.Config.Versions)), slices.Sorted(slices.Values(planPackages(cfg)))) {
+		t.Fatalf("unexpected plan versions: %v", plan.Config.Versions)
+	}
+	again, err := BuildPlan(plan.Config, "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, plan) {
+		t.Fatal("rebuilding a plan from its config must not change it")
+	}
+
+	record := args[stepVersions]
+	if record[3] != versionsFile {
+		t.Fatalf("unexpected versions file %s", record[3])
+	}
+	var recorded map[string]string
+	if err := json.Unmarshal([]byte(record[4]), &recorded); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(recorded, plan.Config.Versions) {
+		t.Fatalf("recorded %v, want %v", recorded, plan.Config.Versions)
+	}
+}
+
+func TestBuildPlanLatest(t *testing.T) {
+	cfg := options.Config{
+		ProjectName: "demo",
+		Framework:   options.FrameworkNext,
+		Database:    options.DatabaseConvex,
+		Versions:    map[string]string{"convex": "1.20.0"},
+		Latest:      true,
+	}
+	plan, err := BuildPlan(cfg, "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text strings.Builder
+	if err := plan.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"create-next-app@latest", "pnpm add --save-exact convex@latest", `"convex": "latest"`} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("expected %q in:\n%s", want, text.String())
+		}
+	}
+}
+
+func TestExecuteRecordsVersions(t *testing.T) {
+	r, f := fakeRunner(t, t.Context())
+	cfg := options.Config{
+		ProjectName:    "app",
+		Framework:      options.FrameworkNext,
+		Auth:           options.AuthBetterAuth,
+		Database:       options.DatabaseNone,
+		Tooling:        []options.ToolingOption{},
+		PackageManager: options.PackageManagerPnpm,
+	}
+	plan, err := BuildPlan(cfg, r.cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.expectAll(scaffoldCommands(cfg, r.cwd))
+
+	if err := r.execute(t.Context(), plan, nil, headless(t, io.Discard)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The record is committed with the project and can be passed back
+	// with --versions.
+	recorded, err := options.LoadVersions(filepath.Join(plan.ProjectPath, versionsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		nextScaffolder: DefaultVersions()[nextScaffolder],
+		"better-auth":  DefaultVersions()["better-auth"],
+	}
+	if !maps.Equal(recorded, want) {
+		t.Fatalf("recorded %v, want %v", recorded, want)
+	}
+}
@@ -0,0 +1,11 @@
+package golden
+
+import "testing"
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc", "a\nB")
+	want := "line 2:\n  - b\n  + B\nline 3:\n  - c\n  + \n"
+	if got != want {
+		t.Fatalf("lineDiff = %q, want %q", got, want)
+	}
+}
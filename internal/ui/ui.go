@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -21,8 +22,10 @@ import (
 var ErrAborted = errors.New("setup cancelled by user")
 
 // Run gathers configuration via Charm-based prompts and returns the user's selections.
-func Run(ctx context.Context, initial options.Config) (options.Config, error) {
-	cfg, err := runForm(ctx, initial)
+// When presets are provided, the first screen offers them as starting points;
+// fields already set on initial always win over the chosen preset.
+func Run(ctx context.Context, initial options.Config, presets []options.Preset) (options.Config, error) {
+	cfg, err := runForm(ctx, initial, presets)
 	if err != nil {
 		if errors.Is(err, huh.ErrUserAborted) || errors.Is(err, context.Canceled) || errors.Is(err, tea.ErrInterrupted) {
 			return options.Config{}, ErrAborted
@@ -40,8 +43,24 @@ func Run(ctx context.Context, initial options.Config) (options.Config, error) {
 	return cfg, nil
 }
 
-func runForm(ctx context.Context, initial options.Config) (options.Config, error) {
-	projectName := defaultString(strings.TrimSpace(initial.ProjectName), "ekko-app")
+func runForm(ctx context.Context, initial options.Config, presets []options.Preset) (options.Config, error) {
+	if len(presets) > 0 {
+		preset, err := runPresetForm(ctx, presets)
+		if err != nil {
+			return options.Config{}, err
+		}
+		initial = options.Overlay(preset.Config, initial)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return options.Config{}, fmt.Errorf("resolve working directory: %w", err)
+	}
+	target, err := resolveTarget(cwd, initial, formPrompts{ctx: ctx})
+	if err != nil {
+		return options.Config{}, err
+	}
+
 	frameworkVal := defaultString(string(initial.Framework), string(options.FrameworkNext))
 	authVal := defaultString(string(initial.Auth), string(options.AuthNone))
 	dbVal := defaultString(string(initial.Database), string(options.DatabaseNone))
@@ -50,22 +69,9 @@ func runForm(ctx context.Context, initial options.Config) (options.Config, error
 		toolSelections[i] = string(tool)
 	}
 	shadcnColor := defaultString(initial.ShadcnColor, "zinc")
+	pmVal := defaultString(string(initial.PackageManager), string(options.DetectPackageManager()))
 
 	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().
-				Title("What is your project called?").
-				Placeholder("ekko-app").
-				Value(&projectName).
-				Validate(func(value string) error {
-					if strings.TrimSpace(value) == "" {
-						return fmt.Errorf("please enter a project name")
-					}
-					return nil
-				}),
-		).WithHideFunc(func() bool {
-			return projectName != "" && initial.ProjectName != ""
-		}),
 		huh.NewGroup(
 			huh.NewSelect[string]().
 				Title("Choose your framework").
@@ -75,6 +81,17 @@ func runForm(ctx context.Context, initial options.Config) (options.Config, error
 				).
 				Value(&frameworkVal),
 		),
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Choose your package manager").
+				Options(
+					huh.NewOption("pnpm", string(options.PackageManagerPnpm)),
+					huh.NewOption("npm", string(options.PackageManagerNpm)),
+					huh.NewOption("Yarn", string(options.PackageManagerYarn)),
+					huh.NewOption("Bun", string(options.PackageManagerBun)),
+				).
+				Value(&pmVal),
+		),
 		huh.NewGroup(
 			huh.NewSelect[string]().
 				Title("Choose your auth package").
@@ -131,11 +148,17 @@ func runForm(ctx context.Context, initial options.Config) (options.Config, error
 	}
 
 	cfg := options.Config{
-		ProjectName: strings.TrimSpace(projectName),
-		Framework:   options.Framework(frameworkVal),
-		Auth:        options.AuthChoice(authVal),
-		Database:    options.DatabaseChoice(dbVal),
-		Tooling:     toToolingOptions(toolSelections),
+		ProjectName:    target.ProjectName,
+		Framework:      options.Framework(frameworkVal),
+		Auth:           options.AuthChoice(authVal),
+		Database:       options.DatabaseChoice(dbVal),
+		Tooling:        toToolingOptions(toolSelections),
+		SkipShadcnOps:  initial.SkipShadcnOps,
+		PackageManager: options.PackageManager(pmVal),
+		Directory:      target.Directory,
+		Versions:       initial.Versions,
+		Latest:         initial.Latest,
+		Force:          target.Force,
 	}
 
 	if contains(toolSelections, string(options.ToolShadcn)) {
@@ -145,14 +168,223 @@ func runForm(ctx context.Context, initial options.Config) (options.Config, error
 	return cfg, nil
 }
 
-func runSummary(ctx context.Context, cfg options.Config) error {
+// Choices offered when the target directory already has content.
+const (
+	conflictAbort  = "abort"
+	conflictForce  = "force"
+	conflictRename = "rename"
+)
+
+// targetPrompts asks the questions resolveTarget needs answered.
+type targetPrompts interface {
+	// projectName asks for a valid project name, starting from current.
+	projectName(current string) (string, error)
+	// directory asks for a valid target directory, starting from current.
+	directory(current string) (string, error)
+	// conflict asks what to do about a non-empty target and returns one of
+	// the conflict* choices offered by conflictOptions.
+	conflict(target string, conflicts []string, hasDirectory bool) (string, error)
+}
+
+// resolveTarget settles the project name and the directory to scaffold into,
+// resolving clashes with an existing, non-empty directory. A valid name
+// passed on the command line skips the name prompt. The returned config is
+// initial with ProjectName, Directory and Force updated.
+//
+// When a directory was given explicitly, it alone decides the target, so a
+// clash is resolved by picking a different directory rather than a
+// different name.
+func resolveTarget(cwd string, initial options.Config, prompts targetPrompts) (options.Config, error) {
+	cfg := initial
+	cfg.ProjectName = defaultString(strings.TrimSpace(initial.ProjectName), "ekko-app")
+	cfg.Force = false
+	askName := initial.ProjectName == "" || options.ValidateProjectName(cfg.ProjectName) != nil
+	askDirectory := false
+
+	for {
+		var err error
+		if askName {
+			if cfg.ProjectName, err = prompts.projectName(cfg.ProjectName); err != nil {
+				return options.Config{}, err
+			}
+		}
+		if askDirectory {
+			if cfg.Directory, err = prompts.directory(cfg.Directory); err != nil {
+				return options.Config{}, err
+			}
+		}
+
+		target := filepath.Join(cwd, cfg.TargetDir())
+		inUse, err := options.DirectoryInUse(target)
+		if err != nil {
+			return options.Config{}, fmt.Errorf("inspect %s: %w", target, err)
+		}
+		if !inUse {
+			return cfg, nil
+		}
+		conflicts, err := options.ScaffoldConflicts(target)
+		if err != nil {
+			return options.Config{}, fmt.Errorf("inspect %s: %w", target, err)
+		}
+		if initial.Force && len(conflicts) == 0 {
+			cfg.Force = true
+			return cfg, nil
+		}
+
+		choice, err := prompts.conflict(target, conflicts, cfg.Directory != "")
+		if err != nil {
+			return options.Config{}, err
+		}
+		switch choice {
+		case conflictForce:
+			cfg.Force = true
+			return cfg, nil
+		case conflictAbort:
+			return options.Config{}, ErrAborted
+		}
+		askName = cfg.Directory == ""
+		askDirectory = !askName
+	}
+}
+
+// formPrompts answers targetPrompts with huh forms. opts are applied after
+// the defaults of every form, so tests can supply input and output.
+type formPrompts struct {
+	ctx  context.Context
+	opts []tea.ProgramOption
+}
+
+func (p formPrompts) projectName(current string) (string, error) {
+	value := current
+	if err := p.run(projectNameInput(&value)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func (p formPrompts) directory(current string) (string, error) {
+	value := current
+	if err := p.run(directoryInput(&value)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func (p formPrompts) conflict(target string, conflicts []string, hasDirectory bool) (string, error) {
+	choice := conflictRename
+	err := p.run(huh.NewSelect[string]().
+		Title(fmt.Sprintf("%s already exists and is not empty", target)).
+		Description(conflictDescription(conflicts)).
+		Options(conflictOptions(conflicts, hasDirectory)...).
+		Value(&choice))
+	if err != nil {
+		return "", err
+	}
+	return choice, nil
+}
+
+func (p formPrompts) run(field huh.Field) error {
+	return huh.NewForm(huh.NewGroup(field)).
+		WithShowHelp(true).
+		WithShowErrors(true).
+		WithTheme(huh.ThemeCharm()).
+		WithProgramOptions(append([]tea.ProgramOption{
+			tea.WithOutput(os.Stderr),
+			tea.WithReportFocus(),
+		}, p.opts...)...).
+		RunWithContext(p.ctx)
+}
+
+func projectNameInput(value *string) *huh.Input {
+	return huh.NewInput().
+		Title("What is your project called?").
+		Placeholder("ekko-app").
+		Value(value).
+		Validate(func(value string) error {
+			return options.ValidateProjectName(strings.TrimSpace(value))
+		})
+}
+
+func directoryInput(value *string) *huh.Input {
+	return huh.NewInput().
+		Title("Which directory should the project go in?").
+		Description("Relative to the current directory.").
+		Value(value).
+		Validate(func(value string) error {
+			return options.ValidateDirectory(strings.TrimSpace(value))
+		})
+}
+
+// conflictOptions offers to scaffold into an existing directory only when
+// the scaffolders accept what it holds, e.g. a fresh clone with just .git
+// and LICENSE. With an explicit directory, the name does not pick the
+// target, so a different directory is offered instead.
+func conflictOptions(conflicts []string, hasDirectory bool) []huh.Option[string] {
+	rename := "Pick a different name"
+	if hasDirectory {
+		rename = "Pick a different directory"
+	}
+	opts := []huh.Option[string]{huh.NewOption(rename, conflictRename)}
+	if len(conflicts) == 0 {
+		opts = append(opts, huh.NewOption("Scaffold into it anyway", conflictForce))
+	}
+	return append(opts, huh.NewOption("Abort", conflictAbort))
+}
+
+// conflictDescription explains why scaffolding into the directory is not
+// offered.
+func conflictDescription(conflicts []string) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("The scaffolder would refuse to overwrite %s.", strings.Join(conflicts, ", "))
+}
+
+func runPresetForm(ctx context.Context, presets []options.Preset) (options.Preset, error) {
+	choices := []huh.Option[int]{huh.NewOption("Start from scratch", -1)}
+	for i, preset := range presets {
+		label := preset.Name
+		if preset.Description != "" {
+			label = fmt.Sprintf("%s — %s", preset.Name, preset.Description)
+		}
+		choices = append(choices, huh.NewOption(label, i))
+	}
+
+	selected := -1
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title("Start from a preset?").
+				Description("Presets only pre-fill the next questions.").
+				Options(choices...).
+				Value(&selected),
+		),
+	).
+		WithShowHelp(true).
+		WithTheme(huh.ThemeCharm())
+
+	if err := form.RunWithContext(ctx); err != nil {
+		return options.Preset{}, err
+	}
+
+	if selected < 0 {
+		return options.Preset{}, nil
+	}
+	return presets[selected], nil
+}
+
+// runSummary shows the selections and waits for the user to confirm them.
+// opts are applied after the defaults, so tests can supply input and output.
+func runSummary(ctx context.Context, cfg options.Config, opts ...tea.ProgramOption) error {
 	items := buildSummaryItems(cfg)
 	model := newSummaryModel(items)
 
 	program := tea.NewProgram(
 		model,
-		tea.WithContext(ctx),
-		tea.WithOutput(os.Stderr),
+		append([]tea.ProgramOption{
+			tea.WithContext(ctx),
+			tea.WithOutput(os.Stderr),
+		}, opts...)...,
 	)
 
 	final, err := program.Run()
@@ -178,6 +410,14 @@ func buildSummaryItems(cfg options.Config) []string {
 		describeFramework(cfg.Framework),
 	}
 
+	if cfg.Directory != "" && cfg.Directory != cfg.ProjectName {
+		items = append(items, fmt.Sprintf("in %s", cfg.Directory))
+	}
+
+	if cfg.PackageManager != "" {
+		items = append(items, string(cfg.PackageManager))
+	}
+
 	if cfg.Auth != options.AuthNone {
 		items = append(items, describeAuth(cfg.Auth))
 	}
@@ -205,6 +445,10 @@ func buildSummaryItems(cfg options.Config) []string {
 		}
 	}
 
+	if cfg.Latest {
+		items = append(items, "latest versions")
+	}
+
 	return items
 }
 
@@ -1,12 +1,31 @@
 package ui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
 	"github.com/mikekenway/create-ekko-app/internal/options"
+	"github.com/mikekenway/create-ekko-app/internal/testutil/golden"
 )
 
+func TestMain(m *testing.M) {
+	// Views are snapshotted without colour, whatever terminal runs the tests.
+	lipgloss.SetColorProfile(termenv.Ascii)
+	os.Exit(m.Run())
+}
+
 func TestBuildSummaryItems(t *testing.T) {
 	cfg := options.Config{
 		ProjectName: "demo",
@@ -45,3 +64,361 @@ func TestToToolingOptions(t *testing.T) {
 		t.Fatalf("unexpected tooling slice: %v", got)
 	}
 }
+
+func TestConflictOptions(t *testing.T) {
+	values := func(opts []huh.Option[string]) []string {
+		var out []string
+		for _, opt := range opts {
+			out = append(out, opt.Value)
+		}
+		return out
+	}
+
+	if got := values(conflictOptions(nil, false)); !slices.Equal(got, []string{conflictRename, conflictForce, conflictAbort}) {
+		t.Fatalf("expected to offer scaffolding into a directory of repository files, got %v", got)
+	}
+	if got := values(conflictOptions([]string{"package.json"}, false)); !slices.Equal(got, []string{conflictRename, conflictAbort}) {
+		t.Fatalf("expected no force option when the scaffolder would refuse, got %v", got)
+	}
+	if got := conflictOptions(nil, true)[0].Key; got != "Pick a different directory" {
+		t.Fatalf("expected a directory prompt when --dir picks the target, got %q", got)
+	}
+}
+
+// scriptedPrompts answers targetPrompts from fixed queues and records the
+// questions asked.
+type scriptedPrompts struct {
+	names       []string
+	directories []string
+	choices     []string
+	asked       []string
+}
+
+func (p *scriptedPrompts) projectName(current string) (string, error) {
+	p.asked = append(p.asked, "name")
+	if len(p.names) == 0 {
+		return "", fmt.Errorf("unexpected name prompt (current %q)", current)
+	}
+	answer := p.names[0]
+	p.names = p.names[1:]
+	return answer, nil
+}
+
+func (p *scriptedPrompts) directory(current string) (string, error) {
+	p.asked = append(p.asked, "directory")
+	if len(p.directories) == 0 {
+		return "", fmt.Errorf("unexpected directory prompt (current %q)", current)
+	}
+	answer := p.directories[0]
+	p.directories = p.directories[1:]
+	return answer, nil
+}
+
+func (p *scriptedPrompts) conflict(target string, _ []string, _ bool) (string, error) {
+	p.asked = append(p.asked, "conflict:"+filepath.Base(target))
+	if len(p.choices) == 0 {
+		return "", fmt.Errorf("unexpected conflict prompt for %s", target)
+	}
+	answer := p.choices[0]
+	p.choices = p.choices[1:]
+	return answer, nil
+}
+
+// occupy creates dir under root holding a file the scaffolders refuse.
+func occupy(t *testing.T, root, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, dir, "package.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveTargetRenameWithDirectoryAsksForDirectory(t *testing.T) {
+	root := t.TempDir()
+	occupy(t, root, "apps/web")
+
+	prompts := &scriptedPrompts{directories: []string{"apps/site"}, choices: []string{conflictRename}}
+	got, err := resolveTarget(root, options.Config{ProjectName: "demo", Directory: "apps/web"}, prompts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ProjectName != "demo" || got.Directory != "apps/site" || got.Force {
+		t.Fatalf("unexpected target %+v", got)
+	}
+	if want := []string{"conflict:web", "directory"}; !slices.Equal(prompts.asked, want) {
+		t.Fatalf("asked %v, want %v", prompts.asked, want)
+	}
+}
+
+func keyPress(key string) tea.KeyMsg {
+	switch key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func quits(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+// revealAll feeds spring ticks to m until every item is shown.
+func revealAll(t *testing.T, m *summaryModel) {
+	t.Helper()
+	for ticks := 0; m.animating; ticks++ {
+		if ticks > 1000 {
+			t.Fatalf("reveal did not finish; stuck at item %d, position %v", m.revealIndex, m.animPos)
+		}
+		_, cmd := m.Update(springMsg{})
+		if !m.animating && cmd != nil {
+			t.Fatal("a finished reveal must stop ticking")
+		}
+	}
+}
+
+var summaryItems = []string{"demo", "Next.js", "pnpm", "Clerk", "Convex", "shadcn (slate)"}
+
+func TestSummaryModelKeys(t *testing.T) {
+	tests := []struct {
+		key       string
+		confirmed bool
+		quits     bool
+	}{
+		{key: "enter", confirmed: true, quits: true},
+		{key: "q", quits: true},
+		{key: "esc", quits: true},
+		{key: "ctrl+c", quits: true},
+		{key: "x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			m := newSummaryModel(summaryItems)
+			_, cmd := m.Update(keyPress(tt.key))
+			if m.confirmed != tt.confirmed || quits(cmd) != tt.quits {
+				t.Fatalf("confirmed=%v quits=%v, want %v %v", m.confirmed, quits(cmd), tt.confirmed, tt.quits)
+			}
+		})
+	}
+}
+
+func TestSummaryModelSpringRevealFinishes(t *testing.T) {
+	m := newSummaryModel(summaryItems)
+	if m.Init() == nil {
+		t.Fatal("expected the reveal to start ticking")
+	}
+	if view := m.View(); strings.Contains(view, "Next.js") {
+		t.Fatalf("only the first item should show before the reveal:\n%s", view)
+	}
+
+	revealAll(t, m)
+	if m.revealIndex != len(summaryItems)-1 || m.animPos != 1 {
+		t.Fatalf("revealIndex=%d animPos=%v", m.revealIndex, m.animPos)
+	}
+	view := m.View()
+	for _, item := range summaryItems {
+		if !strings.Contains(view, item) {
+			t.Fatalf("expected %q once revealed:\n%s", item, view)
+		}
+	}
+	if _, cmd := m.Update(springMsg{}); cmd != nil {
+		t.Fatal("late ticks must not restart the reveal")
+	}
+}
+
+func TestSummaryModelRevealWithoutItems(t *testing.T) {
+	m := newSummaryModel(nil)
+	m.Init()
+	revealAll(t, m)
+	if !strings.Contains(m.View(), "enter to continue") {
+		t.Fatalf("unexpected view:\n%s", m.View())
+	}
+}
+
+func TestSummaryModelView(t *testing.T) {
+	for _, width := range []int{60, 100} {
+		t.Run(fmt.Sprint(width), func(t *testing.T) {
+			m := newSummaryModel(summaryItems)
+			m.Update(tea.WindowSizeMsg{Width: width, Height: 30})
+			if m.width != width || m.height != 30 {
+				t.Fatalf("size = %dx%d", m.width, m.height)
+			}
+			revealAll(t, m)
+			golden.Check(t, fmt.Sprintf("summary-%d.golden", width), m.View())
+		})
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	cfg := options.Config{ProjectName: "demo", Framework: options.FrameworkNext}
+	run := func(ctx context.Context, input string) error {
+		return runSummary(ctx, cfg, tea.WithInput(strings.NewReader(input)), tea.WithOutput(io.Discard))
+	}
+
+	if err := run(t.Context(), "\r"); err != nil {
+		t.Fatalf("enter must confirm: %v", err)
+	}
+	if err := run(t.Context(), "q"); !errors.Is(err, ErrAborted) {
+		t.Fatalf("q must abort, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := run(ctx, ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled context to surface, got %v", err)
+	}
+}
+
+// scriptedForms answers the huh prompts with input typed into them.
+func scriptedForms(ctx context.Context, input string) formPrompts {
+	return formPrompts{ctx: ctx, opts: []tea.ProgramOption{
+		tea.WithInput(strings.NewReader(input)),
+		tea.WithOutput(io.Discard),
+	}}
+}
+
+func TestFormPromptsProjectNameValidates(t *testing.T) {
+	// enter on an invalid name must keep the prompt open; ctrl+u clears it.
+	got, err := scriptedForms(t.Context(), "My App\r\x15my-app\r").projectName("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "my-app" {
+		t.Fatalf("projectName = %q, want the first valid answer", got)
+	}
+}
+
+func TestFormPromptsDirectoryValidates(t *testing.T) {
+	got, err := scriptedForms(t.Context(), "\x15../web\r\x15apps/site\r").directory("apps/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "apps/site" {
+		t.Fatalf("directory = %q, want the first valid answer", got)
+	}
+}
+
+func TestFormPromptsConflictChoices(t *testing.T) {
+	const down = "\x1b[B"
+	tests := []struct {
+		name      string
+		input     string
+		conflicts []string
+		want      string
+	}{
+		{name: "default", input: "\r", want: conflictRename},
+		{name: "force", input: down + "\r", want: conflictForce},
+		{name: "abort", input: down + down + "\r", want: conflictAbort},
+		{name: "abort without force", input: down + "\r", conflicts: []string{"package.json"}, want: conflictAbort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scriptedForms(t.Context(), tt.input).conflict("/work/demo", tt.conflicts, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("choice = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := scriptedForms(t.Context(), "\x03").conflict("/work/demo", nil, false); !errors.Is(err, huh.ErrUserAborted) {
+		t.Fatalf("ctrl+c must abort, got %v", err)
+	}
+}
+
+func TestResolveTargetConflictChoices(t *testing.T) {
+	repo := func(t *testing.T, root string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(root, "demo"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "demo", "LICENSE"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		initial options.Config
+		prompts scriptedPrompts
+		want    options.Config
+		err     error
+		asked   []string
+	}{
+		{
+			name:    "free name",
+			initial: options.Config{ProjectName: "other"},
+			want:    options.Config{ProjectName: "other"},
+		},
+		{
+			name:    "invalid name is asked for",
+			initial: options.Config{ProjectName: "My App"},
+			prompts: scriptedPrompts{names: []string{"other"}},
+			want:    options.Config{ProjectName: "other"},
+			asked:   []string{"name"},
+		},
+		{
+			name:    "rename",
+			initial: options.Config{ProjectName: "demo"},
+			prompts: scriptedPrompts{names: []string{"other"}, choices: []string{conflictRename}},
+			want:    options.Config{ProjectName: "other"},
+			asked:   []string{"conflict:demo", "name"},
+		},
+		{
+			name:    "force",
+			initial: options.Config{ProjectName: "demo"},
+			prompts: scriptedPrompts{choices: []string{conflictForce}},
+			want:    options.Config{ProjectName: "demo", Force: true},
+			asked:   []string{"conflict:demo"},
+		},
+		{
+			name:    "abort",
+			initial: options.Config{ProjectName: "demo"},
+			prompts: scriptedPrompts{choices: []string{conflictAbort}},
+			err:     ErrAborted,
+			asked:   []string{"conflict:demo"},
+		},
+		{
+			name:    "--force skips the prompt",
+			initial: options.Config{ProjectName: "demo", Force: true},
+			want:    options.Config{ProjectName: "demo", Force: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			repo(t, root)
+
+			got, err := resolveTarget(root, tt.initial, &tt.prompts)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got.ProjectName != tt.want.ProjectName || got.Directory != tt.want.Directory || got.Force != tt.want.Force {
+				t.Fatalf("target = %+v, want %+v", got, tt.want)
+			}
+			if !slices.Equal(tt.prompts.asked, tt.asked) {
+				t.Fatalf("asked %v, want %v", tt.prompts.asked, tt.asked)
+			}
+		})
+	}
+
+	t.Run("--force refuses conflicts", func(t *testing.T) {
+		root := t.TempDir()
+		occupy(t, root, "demo")
+		prompts := &scriptedPrompts{choices: []string{conflictAbort}}
+		if _, err := resolveTarget(root, options.Config{ProjectName: "demo", Force: true}, prompts); !errors.Is(err, ErrAborted) {
+			t.Fatalf("expected the conflict prompt to run, got %v", err)
+		}
+	})
+}